@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ARCHIVE_MEMBER_SEPARATOR separates the filename of a ZIP archive from
+// the path of a member within it, as in "archive.zip#path/in/zip.csv".
+const ARCHIVE_MEMBER_SEPARATOR = "#"
+
+// IsZipFilename reports whether a filename has the extension of a ZIP archive.
+func IsZipFilename(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".zip")
+}
+
+// SplitArchiveMember splits a filename of the form "archive.zip#member"
+// into the archive filename and the member path. A filename that exists
+// as it is, even if it contains "#", is not treated as an archive member.
+func SplitArchiveMember(filename string) (archiveFilename, member string, ok bool) {
+	i := strings.Index(strings.ToLower(filename), ".zip"+ARCHIVE_MEMBER_SEPARATOR)
+	if i == -1 {
+		return "", "", false
+	}
+	if _, err := os.Stat(filename); err == nil {
+		return "", "", false
+	}
+	separatorIndex := i + len(".zip")
+	return filename[:separatorIndex], filename[separatorIndex+len(ARCHIVE_MEMBER_SEPARATOR):], true
+}
+
+// ArchiveMemberFilename joins the filename of a ZIP archive and the path
+// of a member within it.
+func ArchiveMemberFilename(archiveFilename, member string) string {
+	return archiveFilename + ARCHIVE_MEMBER_SEPARATOR + member
+}
+
+// ListArchiveCsvs returns the paths of the CSVs in a ZIP archive, in the
+// order they are stored. Directories and the resource forks that macOS
+// adds under "__MACOSX/" are skipped.
+func ListArchiveCsvs(archiveFilename string) ([]string, error) {
+	archive, err := zip.OpenReader(archiveFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	members := make([]string, 0)
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if IsCsvFilename(file.Name) {
+			members = append(members, file.Name)
+		}
+	}
+	return members, nil
+}
+
+// ExpandArchive expands the filename of a ZIP archive into a filename
+// for each of the CSVs within it. Other filenames, including those
+// already naming a member of an archive, are returned as they are.
+func ExpandArchive(filename string) ([]string, error) {
+	if !IsZipFilename(filename) {
+		return []string{filename}, nil
+	}
+	members, err := ListArchiveCsvs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, errors.New("No CSVs in archive " + filename)
+	}
+	filenames := make([]string, len(members))
+	for i, member := range members {
+		filenames[i] = ArchiveMemberFilename(filename, member)
+	}
+	return filenames, nil
+}
+
+// archiveMember is a member of a ZIP archive open for reading, closing
+// both the member and the archive when it is closed.
+type archiveMember struct {
+	archive *zip.ReadCloser
+	member  io.ReadCloser
+}
+
+func (am *archiveMember) Read(p []byte) (int, error) {
+	return am.member.Read(p)
+}
+
+func (am *archiveMember) Close() error {
+	err := am.member.Close()
+	archiveErr := am.archive.Close()
+	if err != nil {
+		return err
+	}
+	return archiveErr
+}
+
+// OpenArchiveMember opens a member of a ZIP archive for reading.
+func OpenArchiveMember(archiveFilename, member string) (*archiveMember, error) {
+	archive, err := zip.OpenReader(archiveFilename)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range archive.File {
+		if file.Name != member && path.Clean(file.Name) != path.Clean(member) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			archive.Close()
+			return nil, err
+		}
+		return &archiveMember{archive: archive, member: rc}, nil
+	}
+	archive.Close()
+	return nil, errors.New("No member " + member + " in archive " + archiveFilename)
+}
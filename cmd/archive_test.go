@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestArchive(t *testing.T, filename string, members map[string]string, order []string) {
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for _, name := range order {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(members[name]))
+	}
+	err = zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveInputCsvs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-archive-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	archiveFilename := filepath.Join(tmpDir, "bundle.zip")
+	writeTestArchive(t, archiveFilename, map[string]string{
+		"b.csv":            "ID,Name\n1,One\n2,Two\n",
+		"nested/a.csv":     "ID,Name,Extra\n3,Three,x\n",
+		"readme.txt":       "Not a CSV\n",
+		"__MACOSX/._b.csv": "junk",
+	}, []string{"b.csv", "readme.txt", "nested/a.csv", "__MACOSX/._b.csv"})
+
+	members, err := ListArchiveCsvs(archiveFilename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if fmt.Sprint(members) != "[b.csv nested/a.csv]" {
+		t.Errorf("Expected members [b.csv nested/a.csv] but got %v", members)
+	}
+
+	inputCsvs, err := GetInputCsvs([]string{archiveFilename}, -1)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(inputCsvs) != 2 {
+		t.Fatalf("Expected 2 inputs but got %d", len(inputCsvs))
+	}
+	if inputCsvs[1].Name() != "a" || inputCsvs[1].IsRegularFile() {
+		t.Errorf("Unexpected archive member input %q", inputCsvs[1].Name())
+	}
+	toc := new(testOutputCsv)
+	StackFiles(inputCsvs, toc, "", nil, STACK_HEADERS_UNION, "")
+	err = assertRowsEqual([][]string{
+		{"ID", "Name", "Extra"},
+		{"1", "One", ""},
+		{"2", "Two", ""},
+		{"3", "Three", "x"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, ic := range inputCsvs {
+		ic.Close()
+	}
+
+	_, err = GetInputCsvs([]string{archiveFilename}, 1)
+	if err == nil {
+		t.Error("Expected error for single input from archive with several CSVs")
+	}
+	inputCsvs, err = GetInputCsvs([]string{archiveFilename + "#nested/a.csv"}, 1)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	numRows, numColumns, err := CountDimensions(inputCsvs[0])
+	inputCsvs[0].Close()
+	if err != nil || numRows != 1 || numColumns != 3 {
+		t.Errorf("Expected 1 row and 3 columns but got %d and %d (%v)", numRows, numColumns, err)
+	}
+	_, err = NewInputCsv(archiveFilename + "#missing.csv")
+	if err == nil {
+		t.Error("Expected error for missing archive member")
+	}
+
+	toc = new(testOutputCsv)
+	err = ListCsvs([]string{"../test-files/simple-sort.csv", archiveFilename}, toc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err = assertRowsEqual([][]string{
+		{"File", "Rows", "Columns"},
+		{"../test-files/simple-sort.csv", "4", "2"},
+		{archiveFilename + "#b.csv", "2", "2"},
+		{archiveFilename + "#nested/a.csv", "1", "3"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
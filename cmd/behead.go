@@ -27,6 +27,9 @@ func (sub *BeheadSubcommand) SetFlags(fs *flag.FlagSet) {
 func (sub *BeheadSubcommand) Run(args []string) {
 	inputCsvs := GetInputCsvsOrPanic(args, 1)
 	outputCsv := NewOutputCsvFromInputCsv(inputCsvs[0])
+	// The rows after those removed are all written, as the input has
+	// no header to leave out.
+	outputCsv.KeepHeader()
 	sub.RunBehead(inputCsvs[0], outputCsv)
 }
 
@@ -39,6 +42,14 @@ func (sub *BeheadSubcommand) RunBehead(inputCsv *InputCsv, outputCsvWriter Outpu
 }
 
 func Behead(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, numHeaders int) {
+	// With --no-header, the rows removed are the first rows of data.
+	if !inputCsv.HasHeader() {
+		_, err := inputCsv.Read()
+		if err != nil && err != io.EOF {
+			ExitWithError(err)
+		}
+	}
+
 	// Get rid of the header rows.
 	for i := 0; i < numHeaders; i++ {
 		_, err := inputCsv.Read()
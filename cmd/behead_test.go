@@ -39,3 +39,24 @@ func TestRunBehead(t *testing.T) {
 		})
 	}
 }
+
+func TestRunBeheadNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	toc := new(testOutputCsv)
+	sub := &BeheadSubcommand{numHeaders: 2}
+	sub.RunBehead(ic, toc)
+	err = assertRowsEqual([][]string{
+		{"2", "Two"},
+		{"-1", "Minus One"},
+		{"2", "Another Two"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -31,6 +31,9 @@ func (sub *CapSubcommand) SetFlags(fs *flag.FlagSet) {
 func (sub *CapSubcommand) Run(args []string) {
 	inputCsvs := GetInputCsvsOrPanic(args, 1)
 	outputCsv := NewOutputCsvFromInputCsv(inputCsvs[0])
+	// The added header is written even with --no-header, which only
+	// means that the first row of the input is data.
+	outputCsv.KeepHeader()
 	sub.RunCap(inputCsvs[0], outputCsv)
 }
 
@@ -44,6 +47,13 @@ func Cap(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, names []string, tr
 	if err != nil {
 		ExitWithError(err)
 	}
+	// The input already has no header, so skip the synthesized one.
+	if !inputCsv.HasHeader() {
+		firstRow, err = inputCsv.Read()
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
 	numColumns := len(firstRow)
 	numNames := len(names)
 	if numColumns > numNames && defaultName == "" {
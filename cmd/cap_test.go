@@ -56,3 +56,27 @@ func TestRunCap(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCapNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	toc := new(testOutputCsv)
+	sub := &CapSubcommand{namesString: "Numero,Cadena"}
+	sub.RunCap(ic, toc)
+	err = assertRowsEqual([][]string{
+		{"Numero", "Cadena"},
+		{"Number", "String"},
+		{"1", "One"},
+		{"2", "Two"},
+		{"-1", "Minus One"},
+		{"2", "Another Two"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
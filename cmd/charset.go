@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	ENCODING_AUTO         = "auto"
+	ENCODING_UTF8         = "utf-8"
+	ENCODING_UTF16LE      = "utf-16le"
+	ENCODING_UTF16BE      = "utf-16be"
+	ENCODING_WINDOWS_1252 = "windows-1252"
+	ENCODING_ISO_8859_1   = "iso-8859-1"
+)
+
+// windows1252HighRunes are the characters of the bytes 0x80 to 0x9F in
+// Windows-1252. The five undefined bytes map to the control characters
+// with the same code points, as they do in ISO-8859-1.
+var windows1252HighRunes = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// ParseEncoding normalizes the name of a character encoding, including
+// "auto" for detecting the encoding. An empty name means UTF-8.
+func ParseEncoding(name string) (string, error) {
+	switch strings.Replace(strings.ToLower(name), "_", "-", -1) {
+	case "", "utf-8", "utf8":
+		return ENCODING_UTF8, nil
+	case "auto":
+		return ENCODING_AUTO, nil
+	case "utf-16le", "utf16le":
+		return ENCODING_UTF16LE, nil
+	case "utf-16be", "utf16be":
+		return ENCODING_UTF16BE, nil
+	case "windows-1252", "cp1252":
+		return ENCODING_WINDOWS_1252, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+		return ENCODING_ISO_8859_1, nil
+	}
+	return "", errors.New("Invalid encoding " + name)
+}
+
+// ParseOutputEncoding normalizes the name of a character encoding for
+// output, for which "auto" is not valid.
+func ParseOutputEncoding(name string) (string, error) {
+	encoding, err := ParseEncoding(name)
+	if err != nil {
+		return "", err
+	}
+	if encoding == ENCODING_AUTO {
+		return "", errors.New("Cannot use encoding auto for output")
+	}
+	return encoding, nil
+}
+
+// IsUtf16Encoding reports whether an encoding is UTF-16.
+func IsUtf16Encoding(encoding string) bool {
+	return encoding == ENCODING_UTF16LE || encoding == ENCODING_UTF16BE
+}
+
+// DetectEncoding detects the character encoding of a stream from the
+// bytes that fit in its buffer, without consuming them. A byte order mark
+// identifies UTF-8 and UTF-16. Otherwise, text with a zero byte in most of
+// its even or odd positions is taken to be UTF-16, valid UTF-8 to be UTF-8,
+// and anything else to be Windows-1252, unless it has none of the
+// characters that Windows-1252 adds to ISO-8859-1.
+func DetectEncoding(r *bufio.Reader) string {
+	sample, _ := r.Peek(r.Size())
+	if bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}) {
+		return ENCODING_UTF8
+	}
+	if bytes.HasPrefix(sample, []byte{0xFF, 0xFE}) {
+		return ENCODING_UTF16LE
+	}
+	if bytes.HasPrefix(sample, []byte{0xFE, 0xFF}) {
+		return ENCODING_UTF16BE
+	}
+
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	numPairs := len(sample) / 2
+	if numPairs > 0 && oddZeros > numPairs/2 && evenZeros <= numPairs/10 {
+		return ENCODING_UTF16LE
+	}
+	if numPairs > 0 && evenZeros > numPairs/2 && oddZeros <= numPairs/10 {
+		return ENCODING_UTF16BE
+	}
+
+	// The sample may end in the middle of a character.
+	for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(sample[i]) {
+			if !utf8.FullRune(sample[i:]) {
+				sample = sample[:i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(sample) {
+		return ENCODING_UTF8
+	}
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F && windows1252HighRunes[b-0x80] != rune(b) {
+			return ENCODING_WINDOWS_1252
+		}
+	}
+	return ENCODING_ISO_8859_1
+}
+
+// decodeFunc decodes as much of src as it can into UTF-8, returning the
+// number of bytes of src consumed. Unless atEOF is true, a character split
+// across the end of src is left to be decoded with the following bytes.
+type decodeFunc func(dst, src []byte, atEOF bool) ([]byte, int)
+
+// decodingReader transcodes a stream in a character encoding into UTF-8.
+type decodingReader struct {
+	r       io.Reader
+	decode  decodeFunc
+	buf     []byte
+	src     []byte
+	decoded []byte
+	err     error
+}
+
+// NewDecodingReader transcodes a stream in a character encoding into UTF-8.
+// A stream in UTF-8 is returned as it is.
+func NewDecodingReader(r io.Reader, encoding string) io.Reader {
+	var decode decodeFunc
+	switch encoding {
+	case ENCODING_UTF16LE:
+		decode = func(dst, src []byte, atEOF bool) ([]byte, int) {
+			return decodeUtf16(dst, src, atEOF, littleEndianUnit)
+		}
+	case ENCODING_UTF16BE:
+		decode = func(dst, src []byte, atEOF bool) ([]byte, int) {
+			return decodeUtf16(dst, src, atEOF, bigEndianUnit)
+		}
+	case ENCODING_WINDOWS_1252:
+		decode = decodeWindows1252
+	case ENCODING_ISO_8859_1:
+		decode = decodeIso88591
+	default:
+		return r
+	}
+	return &decodingReader{r: r, decode: decode, buf: make([]byte, 4096)}
+}
+
+func (dr *decodingReader) Read(p []byte) (int, error) {
+	for len(dr.decoded) == 0 && dr.err == nil {
+		n, err := dr.r.Read(dr.buf)
+		dr.src = append(dr.src, dr.buf[:n]...)
+		dr.err = err
+		var consumed int
+		dr.decoded, consumed = dr.decode(dr.decoded[:0], dr.src, err != nil)
+		dr.src = append(dr.src[:0], dr.src[consumed:]...)
+	}
+	if len(dr.decoded) == 0 {
+		return 0, dr.err
+	}
+	n := copy(p, dr.decoded)
+	dr.decoded = dr.decoded[n:]
+	return n, nil
+}
+
+func appendRune(dst []byte, r rune) []byte {
+	var encoded [utf8.UTFMax]byte
+	n := utf8.EncodeRune(encoded[:], r)
+	return append(dst, encoded[:n]...)
+}
+
+func decodeWindows1252(dst, src []byte, atEOF bool) ([]byte, int) {
+	for _, b := range src {
+		if b >= 0x80 && b <= 0x9F {
+			dst = appendRune(dst, windows1252HighRunes[b-0x80])
+		} else {
+			dst = appendRune(dst, rune(b))
+		}
+	}
+	return dst, len(src)
+}
+
+func decodeIso88591(dst, src []byte, atEOF bool) ([]byte, int) {
+	for _, b := range src {
+		dst = appendRune(dst, rune(b))
+	}
+	return dst, len(src)
+}
+
+func littleEndianUnit(b []byte) rune {
+	return rune(b[0]) | rune(b[1])<<8
+}
+
+func bigEndianUnit(b []byte) rune {
+	return rune(b[0])<<8 | rune(b[1])
+}
+
+func decodeUtf16(dst, src []byte, atEOF bool, unit func([]byte) rune) ([]byte, int) {
+	i := 0
+	for i+1 < len(src) {
+		r := unit(src[i:])
+		if !utf16.IsSurrogate(r) {
+			dst = appendRune(dst, r)
+			i += 2
+			continue
+		}
+		if i+3 >= len(src) && !atEOF {
+			break
+		}
+		if i+3 < len(src) {
+			if decoded := utf16.DecodeRune(r, unit(src[i+2:])); decoded != utf8.RuneError {
+				dst = appendRune(dst, decoded)
+				i += 4
+				continue
+			}
+		}
+		dst = appendRune(dst, utf8.RuneError)
+		i += 2
+	}
+	if atEOF && i < len(src) {
+		dst = appendRune(dst, utf8.RuneError)
+		i = len(src)
+	}
+	return dst, i
+}
+
+// encodingWriter transcodes UTF-8 written to it into a character encoding.
+type encodingWriter struct {
+	w       io.Writer
+	encode  func(dst []byte, r rune) []byte
+	pending []byte
+}
+
+// NewEncodingWriter transcodes UTF-8 into a character encoding as it is
+// written. Characters that the encoding cannot represent are written as
+// "?". For UTF-8, the writer is returned as it is.
+func NewEncodingWriter(w io.Writer, encoding string) io.Writer {
+	var encode func(dst []byte, r rune) []byte
+	switch encoding {
+	case ENCODING_UTF16LE:
+		encode = func(dst []byte, r rune) []byte {
+			for _, u := range encodeUtf16Units(r) {
+				dst = append(dst, byte(u), byte(u>>8))
+			}
+			return dst
+		}
+	case ENCODING_UTF16BE:
+		encode = func(dst []byte, r rune) []byte {
+			for _, u := range encodeUtf16Units(r) {
+				dst = append(dst, byte(u>>8), byte(u))
+			}
+			return dst
+		}
+	case ENCODING_WINDOWS_1252:
+		encode = encodeWindows1252
+	case ENCODING_ISO_8859_1:
+		encode = func(dst []byte, r rune) []byte {
+			if r > 0xFF {
+				return append(dst, '?')
+			}
+			return append(dst, byte(r))
+		}
+	default:
+		return w
+	}
+	return &encodingWriter{w: w, encode: encode}
+}
+
+func encodeUtf16Units(r rune) []uint16 {
+	if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+		return []uint16{uint16(r1), uint16(r2)}
+	}
+	return []uint16{uint16(r)}
+}
+
+func encodeWindows1252(dst []byte, r rune) []byte {
+	if r < 0x80 || (r >= 0xA0 && r <= 0xFF) {
+		return append(dst, byte(r))
+	}
+	for i, highRune := range windows1252HighRunes {
+		if highRune == r {
+			return append(dst, byte(0x80+i))
+		}
+	}
+	return append(dst, '?')
+}
+
+func (ew *encodingWriter) Write(p []byte) (int, error) {
+	src := p
+	if len(ew.pending) > 0 {
+		src = append(ew.pending, p...)
+		ew.pending = nil
+	}
+	encoded := make([]byte, 0, len(src))
+	for len(src) > 0 {
+		if !utf8.FullRune(src) {
+			ew.pending = append([]byte{}, src...)
+			break
+		}
+		r, size := utf8.DecodeRune(src)
+		encoded = ew.encode(encoded, r)
+		src = src[size:]
+	}
+	_, err := ew.w.Write(encoded)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
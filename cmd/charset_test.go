@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	testCases := []struct {
+		contents []byte
+		encoding string
+	}{
+		{[]byte("Name\nJosé\n"), ENCODING_UTF8},
+		{[]byte("\xEF\xBB\xBFName\nJos\xC3\xA9\n"), ENCODING_UTF8},
+		{[]byte("Name\nJos\xE9\n"), ENCODING_ISO_8859_1},
+		{[]byte("Name\n\x93Jos\xE9\x94\n"), ENCODING_WINDOWS_1252},
+		{[]byte("\xFF\xFEN\x00a\x00"), ENCODING_UTF16LE},
+		{[]byte("N\x00a\x00m\x00e\x00\n\x00"), ENCODING_UTF16LE},
+		{[]byte("\x00N\x00a\x00m\x00e\x00\n"), ENCODING_UTF16BE},
+		{[]byte(""), ENCODING_UTF8},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			encoding := DetectEncoding(bufio.NewReader(bytes.NewReader(tt.contents)))
+			if encoding != tt.encoding {
+				t.Errorf("Expected %s but got %s", tt.encoding, encoding)
+			}
+		})
+	}
+}
+
+func TestTranscoding(t *testing.T) {
+	text := "Name,Quote\nJosé,“Ça va” – ok\nEmoji,😀\n"
+	testCases := []struct {
+		encoding string
+		encoded  string
+		lossy    bool
+	}{
+		{ENCODING_UTF8, text, false},
+		{ENCODING_WINDOWS_1252, "Name,Quote\nJos\xE9,\x93\xC7a va\x94 \x96 ok\nEmoji,?\n", true},
+		{ENCODING_ISO_8859_1, "Name,Quote\nJos\xE9,?\xC7a va? ? ok\nEmoji,?\n", true},
+		{ENCODING_UTF16LE, "", false},
+		{ENCODING_UTF16BE, "", false},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			var encoded bytes.Buffer
+			w := NewEncodingWriter(&encoded, tt.encoding)
+			// Write a byte at a time to split characters across writes.
+			for j := 0; j < len(text); j++ {
+				w.Write([]byte{text[j]})
+			}
+			if tt.encoded != "" && encoded.String() != tt.encoded {
+				t.Errorf("Expected %q but got %q", tt.encoded, encoded.String())
+			}
+			decoded, err := ioutil.ReadAll(NewDecodingReader(bytes.NewReader(encoded.Bytes()), tt.encoding))
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			if !tt.lossy && string(decoded) != text {
+				t.Errorf("Expected %q but got %q", text, decoded)
+			}
+		})
+	}
+}
+
+func TestInputCsvEncoding(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-encoding-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	utf16Filename := filepath.Join(tmpDir, "utf16.csv")
+	var utf16Contents bytes.Buffer
+	NewEncodingWriter(&utf16Contents, ENCODING_UTF16LE).Write([]byte(BOM_STRING + "Name,City\nJosé,Zürich\n"))
+	err = ioutil.WriteFile(utf16Filename, utf16Contents.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latinFilename := filepath.Join(tmpDir, "latin.csv")
+	err = ioutil.WriteFile(latinFilename, []byte("Name,City\nJos\xE9,Z\xFCrich\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"Name", "City"},
+		{"José", "Zürich"},
+	}
+	testCases := []struct {
+		filename string
+		encoding string
+		detected string
+		hasBom   bool
+	}{
+		{utf16Filename, ENCODING_AUTO, ENCODING_UTF16LE, true},
+		{latinFilename, ENCODING_AUTO, ENCODING_ISO_8859_1, false},
+		{latinFilename, "cp1252", ENCODING_WINDOWS_1252, false},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsvWithEncoding(tt.filename, tt.encoding)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			defer ic.Close()
+			if ic.Encoding() != tt.detected {
+				t.Errorf("Expected encoding %s but got %s", tt.detected, ic.Encoding())
+			}
+			if ic.hasBom != tt.hasBom {
+				t.Errorf("Expected hasBom %v but got %v", tt.hasBom, ic.hasBom)
+			}
+			if ic.IsRegularFile() {
+				t.Error("Transcoded files should not be treated as seekable")
+			}
+			rows, err := ic.ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			err = assertRowsEqual(expected, rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	_, err = NewInputCsvWithEncoding(latinFilename, "ebcdic")
+	if err == nil {
+		t.Error("Expected error for invalid encoding")
+	}
+}
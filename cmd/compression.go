@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	COMPRESSION_NONE  = ""
+	COMPRESSION_GZIP  = "gzip"
+	COMPRESSION_BZIP2 = "bzip2"
+	COMPRESSION_ZLIB  = "zlib"
+)
+
+// CompressionFromFilename returns the compression format indicated by
+// the extension of a filename.
+func CompressionFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gz", ".gzip":
+		return COMPRESSION_GZIP
+	case ".bz2", ".bzip2":
+		return COMPRESSION_BZIP2
+	case ".zz", ".zlib":
+		return COMPRESSION_ZLIB
+	}
+	return COMPRESSION_NONE
+}
+
+// CompressionExtension returns the filename extension of a compression format.
+func CompressionExtension(compression string) string {
+	switch compression {
+	case COMPRESSION_GZIP:
+		return ".gz"
+	case COMPRESSION_BZIP2:
+		return ".bz2"
+	case COMPRESSION_ZLIB:
+		return ".zz"
+	}
+	return ""
+}
+
+// TrimCompressionExtension removes the extension of a compression format,
+// if any, from a filename, e.g. turning "data.csv.gz" into "data.csv".
+func TrimCompressionExtension(filename string) string {
+	if CompressionFromFilename(filename) == COMPRESSION_NONE {
+		return filename
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// DetectCompression detects the compression format of a stream from its
+// magic bytes, without consuming them. Since the first byte of a zlib
+// stream is "x", only the header bytes that are not printable characters
+// are recognized as zlib.
+func DetectCompression(r *bufio.Reader) string {
+	magic, _ := r.Peek(3)
+	if bytes.HasPrefix(magic, []byte{0x1f, 0x8b}) {
+		return COMPRESSION_GZIP
+	}
+	if bytes.HasPrefix(magic, []byte("BZh")) {
+		return COMPRESSION_BZIP2
+	}
+	if len(magic) >= 2 && magic[0] == 0x78 && (magic[1] == 0x01 || magic[1] == 0x9c || magic[1] == 0xda) {
+		return COMPRESSION_ZLIB
+	}
+	return COMPRESSION_NONE
+}
+
+// NewDecompressingReader decompresses a stream in a compression format.
+func NewDecompressingReader(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case COMPRESSION_GZIP:
+		return gzip.NewReader(r)
+	case COMPRESSION_BZIP2:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case COMPRESSION_ZLIB:
+		return zlib.NewReader(r)
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+// ParseOutputCompression validates a compression format for output.
+// Both "" and "none" mean no compression.
+func ParseOutputCompression(compression string) (string, error) {
+	switch strings.ToLower(compression) {
+	case "", "none":
+		return COMPRESSION_NONE, nil
+	case "gzip", "gz":
+		return COMPRESSION_GZIP, nil
+	case "zlib":
+		return COMPRESSION_ZLIB, nil
+	case "bzip2", "bz2":
+		return "", errors.New("Writing bzip2 is not supported")
+	}
+	return "", errors.New("Invalid compression format " + compression)
+}
+
+// AddCompressionExtension appends the extension of a compression format
+// to a filename, unless the filename already has it.
+func AddCompressionExtension(filename, compression string) string {
+	if compression == COMPRESSION_NONE || CompressionFromFilename(filename) == compression {
+		return filename
+	}
+	return filename + CompressionExtension(compression)
+}
+
+// compressedFile compresses what is written to a file, closing both
+// the compressor and the file when it is closed.
+type compressedFile struct {
+	compressor io.WriteCloser
+	file       *os.File
+}
+
+func (cf *compressedFile) Write(p []byte) (int, error) {
+	return cf.compressor.Write(p)
+}
+
+func (cf *compressedFile) Close() error {
+	err := cf.compressor.Close()
+	fileErr := cf.file.Close()
+	if err != nil {
+		return err
+	}
+	return fileErr
+}
+
+// CreateOutputFile creates a file that is compressed in the compression
+// format. If appendToFile is true, an existing file is appended to instead,
+// which is only possible for uncompressed and gzip files, since a gzip file
+// may consist of several compressed members.
+func CreateOutputFile(filename, compression string, appendToFile bool) (io.WriteCloser, error) {
+	if appendToFile && compression == COMPRESSION_ZLIB {
+		return nil, errors.New("Cannot append to zlib file " + filename)
+	}
+	var file *os.File
+	var err error
+	if appendToFile {
+		file, err = os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	} else {
+		file, err = os.Create(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case COMPRESSION_GZIP:
+		return &compressedFile{compressor: gzip.NewWriter(file), file: file}, nil
+	case COMPRESSION_ZLIB:
+		return &compressedFile{compressor: zlib.NewWriter(file), file: file}, nil
+	case COMPRESSION_BZIP2:
+		file.Close()
+		return nil, errors.New("Writing bzip2 is not supported")
+	}
+	return file, nil
+}
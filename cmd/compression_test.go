@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedInputCsv(t *testing.T) {
+	expected := [][]string{
+		{"Number", "String"},
+		{"1", "One"},
+		{"2", "Two"},
+		{"-1", "Minus One"},
+		{"2", "Another Two"},
+	}
+
+	tmpDir, err := ioutil.TempDir("", "gocsv-compression-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Compressed files without a compression extension.
+	gzipContents, err := ioutil.ReadFile("../test-files/simple-sort.csv.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "gzip.csv"), gzipContents, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csvContents, err := ioutil.ReadFile("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zlibContents bytes.Buffer
+	zw := zlib.NewWriter(&zlibContents)
+	zw.Write(csvContents)
+	zw.Close()
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "zlib.csv"), zlibContents.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		filename    string
+		compression string
+		name        string
+	}{
+		{"../test-files/simple-sort.csv", COMPRESSION_NONE, "simple-sort"},
+		{"../test-files/simple-sort.csv.gz", COMPRESSION_GZIP, "simple-sort"},
+		{"../test-files/simple-sort.csv.bz2", COMPRESSION_BZIP2, "simple-sort"},
+		{filepath.Join(tmpDir, "gzip.csv"), COMPRESSION_GZIP, "gzip"},
+		{filepath.Join(tmpDir, "zlib.csv"), COMPRESSION_ZLIB, "zlib"},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsv(tt.filename)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			defer ic.Close()
+			if ic.compression != tt.compression {
+				t.Errorf("Expected compression %q but got %q", tt.compression, ic.compression)
+			}
+			if ic.Name() != tt.name {
+				t.Errorf("Expected name %q but got %q", tt.name, ic.Name())
+			}
+			if ic.IsRegularFile() != (tt.compression == COMPRESSION_NONE) {
+				t.Error("Compressed files should not be treated as seekable")
+			}
+			rows, err := ic.ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			err = assertRowsEqual(expected, rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSplitOutputCompression(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-compression-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ic, err := NewInputCsv("../test-files/sample.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	// Files are reopened for appending, adding more gzip members.
+	sub := &SplitSubcommand{
+		byString:     "Label",
+		filenameBase: filepath.Join(tmpDir, "sample"),
+		maxOpenFiles: 1,
+		compression:  "gzip",
+	}
+	sub.RunSplit(ic)
+
+	expected := map[string]int{"sample-a.csv.gz": 12, "sample-b.csv.gz": 6, "sample-c.csv.gz": 2}
+	for filename, numRows := range expected {
+		file, err := os.Open(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		magic := make([]byte, 2)
+		file.Read(magic)
+		file.Close()
+		if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+			t.Errorf("Expected %s to be compressed with gzip", filename)
+		}
+		rows := readSplitFile(t, filepath.Join(tmpDir, filename))
+		if len(rows) != numRows+1 {
+			t.Errorf("Expected %d rows in %s but got %d", numRows, filename, len(rows)-1)
+		}
+	}
+}
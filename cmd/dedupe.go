@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	SIMILARITY_EXACT       = "exact"
+	SIMILARITY_LEVENSHTEIN = "levenshtein"
+	SIMILARITY_JACCARD     = "jaccard"
+	SIMILARITY_SOUNDEX     = "soundex"
+	SIMILARITY_METAPHONE   = "metaphone"
+)
+
+type DedupeSubcommand struct {
+	columnsString string
+	blockString   string
+	threshold     float64
+}
+
+func (sub *DedupeSubcommand) Name() string {
+	return "dedupe"
+}
+func (sub *DedupeSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *DedupeSubcommand) Description() string {
+	return "Cluster rows that are near duplicates of each other."
+}
+func (sub *DedupeSubcommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&sub.columnsString, "columns", "", "Columns to compare, with similarity methods and thresholds")
+	fs.StringVar(&sub.columnsString, "c", "", "Columns to compare, with similarity methods and thresholds (shorthand)")
+	fs.StringVar(&sub.blockString, "block", "", "Columns whose values must match for rows to be compared")
+	fs.StringVar(&sub.blockString, "b", "", "Columns whose values must match for rows to be compared (shorthand)")
+	fs.Float64Var(&sub.threshold, "threshold", 0.8, "Default minimum similarity for a column to match")
+}
+
+func (sub *DedupeSubcommand) Run(args []string) {
+	inputCsvs := GetInputCsvsOrPanic(args, 1)
+	outputCsv := NewOutputCsvFromInputCsv(inputCsvs[0])
+	sub.RunDedupe(inputCsvs[0], outputCsv)
+}
+
+func (sub *DedupeSubcommand) RunDedupe(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter) {
+	if sub.columnsString == "" {
+		ExitWithError(errors.New("Missing required argument --columns"))
+	}
+	columns := GetArrayFromCsvString(sub.columnsString)
+	var blockColumns []string
+	if sub.blockString == "" {
+		blockColumns = make([]string, 0)
+	} else {
+		blockColumns = GetArrayFromCsvString(sub.blockString)
+	}
+
+	imc := NewInMemoryCsvFromInputCsv(inputCsv)
+
+	dedupeColumns, err := ParseDedupeColumns(imc.header, columns, sub.threshold)
+	if err != nil {
+		ExitWithError(err)
+	}
+	blockingKeys, err := ParseBlockingKeys(imc.header, blockColumns)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	clusterIds, isRepresentative := ClusterRows(imc.rows, dedupeColumns, blockingKeys)
+
+	// Write header.
+	header := append(imc.header, "Cluster", "Representative")
+	outputCsvWriter.Write(header)
+
+	for i, row := range imc.rows {
+		outputCsvWriter.Write(append(row, strconv.Itoa(clusterIds[i]), strconv.FormatBool(isRepresentative[i])))
+	}
+}
+
+// DedupeColumn is a column compared with a similarity method. Two rows
+// match on the column if their similarity is at least the threshold.
+type DedupeColumn struct {
+	index     int
+	method    string
+	threshold float64
+}
+
+// ParseDedupeColumns resolves column specifications of the form
+// "COLUMN[:METHOD][:THRESHOLD]" against a header. The method defaults to
+// levenshtein and the threshold to defaultThreshold.
+func ParseDedupeColumns(header []string, columns []string, defaultThreshold float64) ([]DedupeColumn, error) {
+	dedupeColumns := make([]DedupeColumn, 0)
+	for _, column := range columns {
+		parts := strings.Split(column, ":")
+		method := SIMILARITY_LEVENSHTEIN
+		threshold := defaultThreshold
+		if len(parts) > 1 {
+			if value, err := strconv.ParseFloat(parts[len(parts)-1], 64); err == nil {
+				threshold = value
+				parts = parts[:len(parts)-1]
+			}
+		}
+		if len(parts) > 1 && isSimilarityMethod(parts[len(parts)-1]) {
+			method = strings.ToLower(parts[len(parts)-1])
+			parts = parts[:len(parts)-1]
+		}
+		if threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("Invalid threshold for column \"%s\"", column)
+		}
+		indices, err := GetIndicesForColumn(header, strings.Join(parts, ":"))
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indices {
+			dedupeColumns = append(dedupeColumns, DedupeColumn{index: index, method: method, threshold: threshold})
+		}
+	}
+	return dedupeColumns, nil
+}
+
+func isSimilarityMethod(method string) bool {
+	switch strings.ToLower(method) {
+	case SIMILARITY_EXACT, SIMILARITY_LEVENSHTEIN, SIMILARITY_JACCARD, SIMILARITY_SOUNDEX, SIMILARITY_METAPHONE:
+		return true
+	}
+	return false
+}
+
+// Similarity computes the similarity of two values, between 0 and 1,
+// using the column's method.
+func (dc DedupeColumn) Similarity(a, b string) float64 {
+	switch dc.method {
+	case SIMILARITY_EXACT:
+		if a == b {
+			return 1.0
+		}
+		return 0.0
+	case SIMILARITY_JACCARD:
+		return TokenJaccardSimilarity(a, b)
+	case SIMILARITY_SOUNDEX:
+		return PhoneticSimilarity(a, b, Soundex)
+	case SIMILARITY_METAPHONE:
+		return PhoneticSimilarity(a, b, Metaphone)
+	default:
+		return LevenshteinSimilarity(a, b)
+	}
+}
+
+// BlockingKey is a column whose (possibly transformed) values must be equal
+// for two rows to be compared at all.
+type BlockingKey struct {
+	index     int
+	transform string
+}
+
+// ParseBlockingKeys resolves column specifications of the form
+// "COLUMN[:TRANSFORM]" against a header. The transform is one of "exact"
+// (the default), "lower", "soundex" or "metaphone".
+func ParseBlockingKeys(header []string, columns []string) ([]BlockingKey, error) {
+	blockingKeys := make([]BlockingKey, 0)
+	for _, column := range columns {
+		parts := strings.Split(column, ":")
+		transform := SIMILARITY_EXACT
+		if len(parts) > 1 {
+			switch last := strings.ToLower(parts[len(parts)-1]); last {
+			case SIMILARITY_EXACT, "lower", SIMILARITY_SOUNDEX, SIMILARITY_METAPHONE:
+				transform = last
+				parts = parts[:len(parts)-1]
+			}
+		}
+		indices, err := GetIndicesForColumn(header, strings.Join(parts, ":"))
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indices {
+			blockingKeys = append(blockingKeys, BlockingKey{index: index, transform: transform})
+		}
+	}
+	return blockingKeys, nil
+}
+
+// Value returns the transformed value of the blocking key for a row.
+func (bk BlockingKey) Value(row []string) string {
+	value := row[bk.index]
+	switch bk.transform {
+	case "lower":
+		return NormalizeForComparison(value)
+	case SIMILARITY_SOUNDEX:
+		return strings.Join(encodeTokens(value, Soundex), " ")
+	case SIMILARITY_METAPHONE:
+		return strings.Join(encodeTokens(value, Metaphone), " ")
+	default:
+		return value
+	}
+}
+
+// ClusterRows groups rows into clusters of near duplicates. Rows are only
+// compared with other rows having the same blocking key values, and two rows
+// are linked if they match on every dedupe column. Clusters are the connected
+// components of the linked rows, so a cluster may contain rows that do not
+// directly match each other.
+//
+// Cluster IDs are numbered from 1 in order of each cluster's first row, and
+// the first row of each cluster is its representative.
+func ClusterRows(rows [][]string, dedupeColumns []DedupeColumn, blockingKeys []BlockingKey) (clusterIds []int, isRepresentative []bool) {
+	blocks := make(map[string][]int)
+	blockKeys := make([]string, 0)
+	keyValues := make([]string, len(blockingKeys))
+	keyIndices := make([]int, len(blockingKeys))
+	for i := range keyIndices {
+		keyIndices[i] = i
+	}
+	for rowIndex, row := range rows {
+		for i, blockingKey := range blockingKeys {
+			keyValues[i] = blockingKey.Value(row)
+		}
+		blockKey := GetRowKey(keyValues, keyIndices)
+		if _, ok := blocks[blockKey]; !ok {
+			blockKeys = append(blockKeys, blockKey)
+		}
+		blocks[blockKey] = append(blocks[blockKey], rowIndex)
+	}
+
+	uf := newUnionFind(len(rows))
+	for _, blockKey := range blockKeys {
+		block := blocks[blockKey]
+		for i, rowIndexA := range block {
+			for _, rowIndexB := range block[i+1:] {
+				if uf.find(rowIndexA) == uf.find(rowIndexB) {
+					continue
+				}
+				if rowsAreSimilar(rows[rowIndexA], rows[rowIndexB], dedupeColumns) {
+					uf.union(rowIndexA, rowIndexB)
+				}
+			}
+		}
+	}
+
+	clusterIds = make([]int, len(rows))
+	isRepresentative = make([]bool, len(rows))
+	rootToClusterId := make(map[int]int)
+	for rowIndex := range rows {
+		root := uf.find(rowIndex)
+		clusterId, ok := rootToClusterId[root]
+		if !ok {
+			clusterId = len(rootToClusterId) + 1
+			rootToClusterId[root] = clusterId
+			isRepresentative[rowIndex] = true
+		}
+		clusterIds[rowIndex] = clusterId
+	}
+	return
+}
+
+func rowsAreSimilar(rowA, rowB []string, dedupeColumns []DedupeColumn) bool {
+	for _, dc := range dedupeColumns {
+		if dc.Similarity(rowA[dc.index], rowB[dc.index]) < dc.threshold {
+			return false
+		}
+	}
+	return true
+}
+
+type unionFind struct {
+	parents []int
+}
+
+func newUnionFind(n int) *unionFind {
+	uf := &unionFind{parents: make([]int, n)}
+	for i := range uf.parents {
+		uf.parents[i] = i
+	}
+	return uf
+}
+
+func (uf *unionFind) find(i int) int {
+	for uf.parents[i] != i {
+		uf.parents[i] = uf.parents[uf.parents[i]]
+		i = uf.parents[i]
+	}
+	return i
+}
+
+func (uf *unionFind) union(i, j int) {
+	rootI := uf.find(i)
+	rootJ := uf.find(j)
+	if rootI == rootJ {
+		return
+	}
+	// Keep the earlier row as the root.
+	if rootI < rootJ {
+		uf.parents[rootJ] = rootI
+	} else {
+		uf.parents[rootI] = rootJ
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRunDedupe(t *testing.T) {
+	testCases := []struct {
+		columnsString string
+		blockString   string
+		rows          [][]string
+	}{
+		{"Name:levenshtein:0.8,Company:jaccard:0.5", "City", [][]string{
+			[]string{"Name", "Company", "City", "Cluster", "Representative"},
+			[]string{"Jon Smith", "ACME Inc", "Boston", "1", "true"},
+			[]string{"John Smith", "Acme", "Boston", "1", "false"},
+			[]string{"Jane Doe", "Globex", "Chicago", "2", "true"},
+			[]string{"Jon Smyth", "ACME Inc", "Boston", "1", "false"},
+			[]string{"Janet Doe", "Initech", "Chicago", "3", "true"},
+			[]string{"John Smith", "Acme", "Denver", "4", "true"},
+		}},
+		{"Name:soundex:1", "", [][]string{
+			[]string{"Name", "Company", "City", "Cluster", "Representative"},
+			[]string{"Jon Smith", "ACME Inc", "Boston", "1", "true"},
+			[]string{"John Smith", "Acme", "Boston", "1", "false"},
+			[]string{"Jane Doe", "Globex", "Chicago", "2", "true"},
+			[]string{"Jon Smyth", "ACME Inc", "Boston", "1", "false"},
+			[]string{"Janet Doe", "Initech", "Chicago", "3", "true"},
+			[]string{"John Smith", "Acme", "Denver", "1", "false"},
+		}},
+		{"Name:exact", "Company:lower", [][]string{
+			[]string{"Name", "Company", "City", "Cluster", "Representative"},
+			[]string{"Jon Smith", "ACME Inc", "Boston", "1", "true"},
+			[]string{"John Smith", "Acme", "Boston", "2", "true"},
+			[]string{"Jane Doe", "Globex", "Chicago", "3", "true"},
+			[]string{"Jon Smyth", "ACME Inc", "Boston", "4", "true"},
+			[]string{"Janet Doe", "Initech", "Chicago", "5", "true"},
+			[]string{"John Smith", "Acme", "Denver", "2", "false"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsv("../test-files/dedupe.csv")
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(DedupeSubcommand)
+			sub.columnsString = tt.columnsString
+			sub.blockString = tt.blockString
+			sub.threshold = 0.8
+			sub.RunDedupe(ic, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPhoneticCodes(t *testing.T) {
+	testCases := []struct {
+		word      string
+		soundex   string
+		metaphone string
+	}{
+		{"Robert", "R163", "RBRT"},
+		{"Rupert", "R163", "RPRT"},
+		{"Ashcraft", "A261", "AXKRFT"},
+		{"Tymczak", "T522", "TMKSK"},
+		{"Smith", "S530", "SM0"},
+		{"Smyth", "S530", "SM0"},
+		{"Knight", "K523", "NT"},
+		{"Thompson", "T512", "0MPSN"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.word, func(t *testing.T) {
+			if soundex := Soundex(tt.word); soundex != tt.soundex {
+				t.Errorf("Expected Soundex %q but got %q", tt.soundex, soundex)
+			}
+			if metaphone := Metaphone(tt.word); metaphone != tt.metaphone {
+				t.Errorf("Expected Metaphone %q but got %q", tt.metaphone, metaphone)
+			}
+		})
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"Jon", "John", 1},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if distance := LevenshteinDistance(tt.a, tt.b); distance != tt.distance {
+				t.Errorf("Expected %d but got %d", tt.distance, distance)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"io"
+	"strconv"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -33,22 +36,24 @@ func (sub *DelimiterSubcommand) Run(args []string) {
 }
 
 func ChangeDelimiter(inputCsv *InputCsv, inputDelimiter, outputDelimiter string) {
-	if inputDelimiter == "\\t" {
-		inputCsv.SetDelimiter('\t')
-	} else if len(inputDelimiter) > 0 {
-		delimiterRune, _ := utf8.DecodeRuneInString(inputDelimiter)
-		inputCsv.SetDelimiter(delimiterRune)
+	if len(inputDelimiter) > 0 {
+		delimiter, err := ParseMultiCharDelimiter(inputDelimiter)
+		if err != nil {
+			ExitWithError(err)
+		}
+		inputCsv.SetDelimiterString(delimiter)
 	}
 	// Be lenient when reading in the file.
 	inputCsv.SetFieldsPerRecord(-1)
 	inputCsv.SetLazyQuotes(true)
 
 	outputCsv := NewOutputCsvFromInputCsv(inputCsv)
-	if outputDelimiter == "\\t" {
-		outputCsv.SetDelimiter('\t')
-	} else if len(outputDelimiter) > 0 {
-		delimiterRune, _ := utf8.DecodeRuneInString(outputDelimiter)
-		outputCsv.SetDelimiter(delimiterRune)
+	if len(outputDelimiter) > 0 {
+		delimiter, err := ParseMultiCharDelimiter(outputDelimiter)
+		if err != nil {
+			ExitWithError(err)
+		}
+		outputCsv.SetDelimiterString(delimiter)
 	}
 
 	// Write all rows with tabs.
@@ -64,3 +69,29 @@ func ChangeDelimiter(inputCsv *InputCsv, inputDelimiter, outputDelimiter string)
 		outputCsv.Write(row)
 	}
 }
+
+// ParseDelimiter parses a delimiter given on the command line, where
+// "\t" stands for a tab.
+func ParseDelimiter(delimiter string) (rune, error) {
+	if delimiter == "\\t" {
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return 0, errors.New("Invalid delimiter " + strconv.Quote(delimiter))
+	}
+	r, _ := utf8.DecodeRuneInString(delimiter)
+	return r, nil
+}
+
+// ParseMultiCharDelimiter parses a delimiter given on the command line
+// like ParseDelimiter, but allows it to be more than one character long,
+// such as "||" or "~|~".
+func ParseMultiCharDelimiter(delimiter string) (string, error) {
+	if delimiter == "\\t" {
+		return "\t", nil
+	}
+	if delimiter == "" || !utf8.ValidString(delimiter) || strings.ContainsAny(delimiter, "\r\n") {
+		return "", errors.New("Invalid delimiter " + strconv.Quote(delimiter))
+	}
+	return delimiter, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMultiCharDelimiter(t *testing.T) {
+	testCases := []struct {
+		delimiter string
+		expected  string
+		isValid   bool
+	}{
+		{",", ",", true},
+		{"\\t", "\t", true},
+		{"||", "||", true},
+		{"~|~", "~|~", true},
+		{"", "", false},
+		{"|\n", "", false},
+		{"\xff", "", false},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			delimiter, err := ParseMultiCharDelimiter(tt.delimiter)
+			if tt.isValid && err != nil {
+				t.Fatal("Unexpected error", err)
+			} else if !tt.isValid && err == nil {
+				t.Fatalf("Expected an error for %q", tt.delimiter)
+			}
+			if delimiter != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, delimiter)
+			}
+		})
+	}
+}
+
+func TestMultiCharDelimiter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-delimiter-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "vendor.txt")
+	err = ioutil.WriteFile(filename, []byte("Name~|~Note\nAlice~|~a|\nBob~|~\"x~|~y\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	ic.SetDelimiterString("~|~")
+	var buf bytes.Buffer
+	oc := NewOutputCsvFromWriter(&buf)
+	oc.SetDelimiterString("||")
+	rows, err := ic.ReadAll()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	for _, row := range rows {
+		oc.Write(row)
+	}
+	expected := "Name||Note\nAlice||\"a|\"\nBob||x~|~y\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
@@ -30,27 +30,14 @@ func (sub *DimensionsSubcommand) Run(args []string) {
 }
 
 func GetDimensions(inputCsv *InputCsv, asCsv bool) {
-	header, err := inputCsv.Read()
+	numRows, numColumns, err := CountDimensions(inputCsv)
 	if err != nil {
 		ExitWithError(err)
 	}
-	numColumns := len(header)
-
-	numRows := 0
-	for {
-		_, err := inputCsv.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				ExitWithError(err)
-			}
-		}
-		numRows++
-	}
 
 	if asCsv {
 		outputCsv := NewOutputCsvFromInputCsv(inputCsv)
+		outputCsv.KeepHeader()
 		outputCsv.Write([]string{"Dimension", "Size"})
 		outputCsv.Write([]string{"Rows", strconv.Itoa(numRows)})
 		outputCsv.Write([]string{"Columns", strconv.Itoa(numColumns)})
@@ -60,3 +47,23 @@ func GetDimensions(inputCsv *InputCsv, asCsv bool) {
 		fmt.Printf("  Columns: %d\n", numColumns)
 	}
 }
+
+// CountDimensions counts the rows, not including the header, and the
+// columns of a CSV.
+func CountDimensions(inputCsv *InputCsv) (numRows, numColumns int, err error) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		return
+	}
+	numColumns = len(header)
+	for {
+		_, err = inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		numRows++
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+)
+
+type EncodingSubcommand struct {
+	asCsv bool
+}
+
+func (sub *EncodingSubcommand) Name() string {
+	return "encoding"
+}
+func (sub *EncodingSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *EncodingSubcommand) Description() string {
+	return "Detect the character encoding of a CSV."
+}
+func (sub *EncodingSubcommand) SetFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&sub.asCsv, "csv", false, "Output results as CSV")
+}
+
+func (sub *EncodingSubcommand) Run(args []string) {
+	filenames := args
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	expanded, err := expandArchives(filenames, -1)
+	if err != nil {
+		ExitWithError(err)
+	}
+	var outputCsv *OutputCsv
+	if sub.asCsv {
+		outputCsv = NewOutputCsv()
+		outputCsv.KeepHeader()
+		outputCsv.Write([]string{"File", "Encoding", "BOM"})
+	}
+	for _, filename := range expanded {
+		encoding, hasBom, err := DetectFileEncoding(filename)
+		if err != nil {
+			ExitWithError(err)
+		}
+		if sub.asCsv {
+			outputCsv.Write([]string{filename, encoding, fmt.Sprint(hasBom)})
+		} else if len(expanded) > 1 {
+			fmt.Printf("%s: %s\n", filename, describeEncoding(encoding, hasBom))
+		} else {
+			fmt.Println(describeEncoding(encoding, hasBom))
+		}
+	}
+}
+
+// DetectFileEncoding detects the character encoding of a CSV and whether
+// it starts with a byte order mark.
+func DetectFileEncoding(filename string) (encoding string, hasBom bool, err error) {
+	inputCsv, err := NewInputCsvWithEncoding(filename, ENCODING_AUTO)
+	if err != nil {
+		return
+	}
+	defer inputCsv.Close()
+	return inputCsv.Encoding(), inputCsv.hasBom, nil
+}
+
+func describeEncoding(encoding string, hasBom bool) string {
+	if hasBom {
+		return encoding + " (with BOM)"
+	}
+	return encoding
+}
@@ -0,0 +1,504 @@
+package cmd
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DEFAULT_SORT_MAX_MEMORY is the default number of bytes of rows that
+// an ExternalSorter holds in memory before spilling them to disk.
+const DEFAULT_SORT_MAX_MEMORY = 256 * 1024 * 1024
+
+// SORT_MAX_OPEN_RUNS is the most runs that an ExternalSorter merges at
+// once, so that a large sort does not run out of file descriptors.
+const SORT_MAX_OPEN_RUNS = 64
+
+// ExternalSorter sorts an arbitrary number of rows using a bounded amount
+// of memory. Rows are buffered in memory until the memory budget is reached,
+// at which point the buffer is sorted and spilled to a temporary file as a
+// sorted "run". Once all rows have been added, the runs are combined with a
+// k-way merge, in several passes if there are too many runs to have open at
+// once. The sort is stable: rows that compare equal are written in the
+// order in which they were added.
+//
+// Column types are inferred incrementally as rows are added. Since a run may
+// have been sorted with a stricter type than the final inferred type (e.g. a
+// column that looked like integers in the first run but contains strings in
+// a later one), any such run is re-sorted before the merge.
+type ExternalSorter struct {
+	sortColumns []SortColumn
+	noInference bool
+	maxMemory   int64
+	maxOpenRuns int
+
+	rows       [][]string
+	rowsMemory int64
+
+	tmpDir      string
+	runs        []*sortRun
+	numRunFiles int
+}
+
+type sortRun struct {
+	filename    string
+	sortColumns []SortColumn
+}
+
+func NewExternalSorter(sortColumns []SortColumn, noInference bool, maxMemory int64) *ExternalSorter {
+	es := new(ExternalSorter)
+	es.sortColumns = make([]SortColumn, len(sortColumns))
+	copy(es.sortColumns, sortColumns)
+	for i := range es.sortColumns {
+		if noInference {
+			es.sortColumns[i].columnType = STRING_TYPE
+		} else {
+			es.sortColumns[i].columnType = NULL_TYPE
+		}
+	}
+	es.noInference = noInference
+	es.maxMemory = maxMemory
+	es.maxOpenRuns = SORT_MAX_OPEN_RUNS
+	return es
+}
+
+// Add buffers a row for sorting, spilling the buffer to disk if
+// the memory budget has been exceeded.
+func (es *ExternalSorter) Add(row []string) error {
+	if !es.noInference {
+		for i := range es.sortColumns {
+			es.sortColumns[i].UpdateType(row[es.sortColumns[i].index])
+		}
+	}
+	es.rows = append(es.rows, row)
+	es.rowsMemory += estimateRowMemory(row)
+	if es.rowsMemory >= es.maxMemory {
+		return es.spill()
+	}
+	return nil
+}
+
+// WriteSorted writes all of the added rows in sorted order and then
+// removes any temporary files.
+func (es *ExternalSorter) WriteSorted(outputCsvWriter OutputCsvWriter) error {
+	defer es.cleanup()
+
+	// Everything fit in memory, so there is no need to merge.
+	if len(es.runs) == 0 {
+		es.sortRows(es.rows, es.sortColumns)
+		for _, row := range es.rows {
+			outputCsvWriter.Write(row)
+		}
+		return nil
+	}
+
+	if len(es.rows) > 0 {
+		err := es.spill()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, run := range es.runs {
+		if !sortColumnTypesEqual(run.sortColumns, es.sortColumns) {
+			err := es.resortRun(run)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return es.mergeRuns(outputCsvWriter)
+}
+
+func (es *ExternalSorter) sortRows(rows [][]string, sortColumns []SortColumn) {
+	isLessFunc := func(row1Ptr, row2Ptr *[]string) bool {
+		return CompareRows(*row1Ptr, *row2Ptr, sortColumns) < 0
+	}
+	SortRowsBy(isLessFunc).Stable(rows)
+}
+
+func (es *ExternalSorter) spill() (err error) {
+	if es.tmpDir == "" {
+		es.tmpDir, err = ioutil.TempDir("", "gocsv-sort-")
+		if err != nil {
+			return
+		}
+	}
+	run := es.newRun()
+	es.sortRows(es.rows, run.sortColumns)
+	err = writeRowsToFile(run.filename, es.rows)
+	if err != nil {
+		return
+	}
+	es.runs = append(es.runs, run)
+	es.rows = nil
+	es.rowsMemory = 0
+	return
+}
+
+func (es *ExternalSorter) newRun() *sortRun {
+	run := new(sortRun)
+	run.filename = filepath.Join(es.tmpDir, "run-"+strconv.Itoa(es.numRunFiles))
+	es.numRunFiles++
+	run.sortColumns = make([]SortColumn, len(es.sortColumns))
+	copy(run.sortColumns, es.sortColumns)
+	return run
+}
+
+func (es *ExternalSorter) resortRun(run *sortRun) error {
+	rows, err := readRowsFromFile(run.filename)
+	if err != nil {
+		return err
+	}
+	copy(run.sortColumns, es.sortColumns)
+	es.sortRows(rows, run.sortColumns)
+	return writeRowsToFile(run.filename, rows)
+}
+
+func (es *ExternalSorter) mergeRuns(outputCsvWriter OutputCsvWriter) error {
+	// Merge consecutive groups of runs into longer runs until there are
+	// few enough to merge at once. Keeping the groups in order keeps the
+	// sort stable.
+	for len(es.runs) > es.maxOpenRuns {
+		var merged []*sortRun
+		for start := 0; start < len(es.runs); start += es.maxOpenRuns {
+			end := start + es.maxOpenRuns
+			if end > len(es.runs) {
+				end = len(es.runs)
+			}
+			run, err := es.mergeRunsToRun(es.runs[start:end])
+			if err != nil {
+				return err
+			}
+			merged = append(merged, run)
+		}
+		es.runs = merged
+	}
+	return es.mergeRunsTo(es.runs, outputCsvWriter)
+}
+
+func (es *ExternalSorter) mergeRunsTo(runs []*sortRun, outputCsvWriter OutputCsvWriter) error {
+	readers := make([]RowReader, len(runs))
+	for i, run := range runs {
+		file, err := os.Open(run.filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		readers[i] = newRunFileReader(file)
+	}
+	return MergeSortedRows(readers, es.sortColumns, outputCsvWriter)
+}
+
+func (es *ExternalSorter) mergeRunsToRun(runs []*sortRun) (*sortRun, error) {
+	run := es.newRun()
+	file, err := os.Create(run.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	writer := newRunFileWriter(file)
+	err = es.mergeRunsTo(runs, writer)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return nil, err
+	}
+	for _, mergedRun := range runs {
+		os.Remove(mergedRun.filename)
+	}
+	return run, file.Close()
+}
+
+func (es *ExternalSorter) cleanup() {
+	if es.tmpDir != "" {
+		os.RemoveAll(es.tmpDir)
+		es.tmpDir = ""
+	}
+	es.runs = nil
+}
+
+// RowReader is implemented by anything that reads rows one at a time,
+// such as an InputCsv or a csv.Reader.
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// MergeSortedRows performs a k-way merge of readers whose rows are already
+// sorted on the sort columns, writing the rows in sorted order. Rows that
+// compare equal are written in the order of the readers. An error is returned
+// if any reader turns out not to be sorted.
+func MergeSortedRows(readers []RowReader, sortColumns []SortColumn, outputCsvWriter OutputCsvWriter) error {
+	rh := &runHeap{sortColumns: sortColumns}
+	for i, reader := range readers {
+		rr := &runReader{index: i, reader: reader}
+		ok, err := rr.next(sortColumns)
+		if err != nil {
+			return err
+		}
+		if ok {
+			rh.readers = append(rh.readers, rr)
+		}
+	}
+	heap.Init(rh)
+
+	for rh.Len() > 0 {
+		rr := rh.readers[0]
+		outputCsvWriter.Write(rr.row)
+		ok, err := rr.next(sortColumns)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(rh, 0)
+		} else {
+			heap.Pop(rh)
+		}
+	}
+	return nil
+}
+
+// MergeSortedRowsInferringTypes is like MergeSortedRows, but first infers
+// the types of the sort columns from all of the rows, as sort does, so that
+// every row is compared using the final types. Since a reader may only be
+// read once, as standard input can, its rows are copied to a temporary
+// file while the types are inferred, and are merged from there.
+func MergeSortedRowsInferringTypes(readers []RowReader, sortColumns []SortColumn, outputCsvWriter OutputCsvWriter) error {
+	for i := range sortColumns {
+		sortColumns[i].columnType = NULL_TYPE
+	}
+	tmpDir, err := ioutil.TempDir("", "gocsv-merge-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	spooledReaders := make([]RowReader, len(readers))
+	for i, reader := range readers {
+		filename := filepath.Join(tmpDir, "input-"+strconv.Itoa(i))
+		file, err := spoolRowsInferringTypes(reader, sortColumns, filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		spooledReaders[i] = newRunFileReader(file)
+	}
+	return MergeSortedRows(spooledReaders, sortColumns, outputCsvWriter)
+}
+
+// spoolRowsInferringTypes copies the rows of a reader to a new run file
+// while updating the types of the sort columns, and returns the file
+// positioned at its start.
+func spoolRowsInferringTypes(reader RowReader, sortColumns []SortColumn, filename string) (*os.File, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	writer := newRunFileWriter(file)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			file.Close()
+			return nil, err
+		}
+		for i := range sortColumns {
+			sortColumns[i].UpdateType(row[sortColumns[i].index])
+		}
+		writer.Write(row)
+	}
+	err = writer.Flush()
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// runReader holds the current row of a sorted reader being merged.
+type runReader struct {
+	index  int
+	reader RowReader
+	row    []string
+}
+
+func (rr *runReader) next(sortColumns []SortColumn) (bool, error) {
+	row, err := rr.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	if rr.row != nil && CompareRows(rr.row, row, sortColumns) > 0 {
+		return false, fmt.Errorf("Input %d is not sorted", rr.index+1)
+	}
+	rr.row = row
+	return true, nil
+}
+
+// runHeap is a min-heap of runReaders ordered by their current rows.
+// Ties are broken by the order of the runs so that rows spilled earlier
+// are written first.
+type runHeap struct {
+	readers     []*runReader
+	sortColumns []SortColumn
+}
+
+func (rh *runHeap) Len() int {
+	return len(rh.readers)
+}
+func (rh *runHeap) Swap(i, j int) {
+	rh.readers[i], rh.readers[j] = rh.readers[j], rh.readers[i]
+}
+func (rh *runHeap) Less(i, j int) bool {
+	cmp := CompareRows(rh.readers[i].row, rh.readers[j].row, rh.sortColumns)
+	if cmp == 0 {
+		return rh.readers[i].index < rh.readers[j].index
+	}
+	return cmp < 0
+}
+func (rh *runHeap) Push(x interface{}) {
+	rh.readers = append(rh.readers, x.(*runReader))
+}
+func (rh *runHeap) Pop() interface{} {
+	n := len(rh.readers)
+	rr := rh.readers[n-1]
+	rh.readers = rh.readers[:n-1]
+	return rr
+}
+
+// estimateRowMemory approximates the number of bytes used to hold
+// a row in memory, including the slice and string headers.
+func estimateRowMemory(row []string) int64 {
+	size := int64(24 + 16*len(row))
+	for _, cell := range row {
+		size += int64(len(cell))
+	}
+	return size
+}
+
+func sortColumnTypesEqual(a, b []SortColumn) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].columnType != b[i].columnType {
+			return false
+		}
+	}
+	return true
+}
+
+// runFileWriter writes rows to a run file. Each row is written as its
+// number of fields followed by the length and bytes of each field, so
+// that fields are read back exactly as they were, unlike a CSV in which
+// \r\n within a quoted field would be read as \n.
+type runFileWriter struct {
+	w *bufio.Writer
+}
+
+func newRunFileWriter(w io.Writer) *runFileWriter {
+	return &runFileWriter{w: bufio.NewWriter(w)}
+}
+
+func (rw *runFileWriter) Write(row []string) error {
+	rw.writeUvarint(len(row))
+	for _, cell := range row {
+		rw.writeUvarint(len(cell))
+		rw.w.WriteString(cell)
+	}
+	// The bufio.Writer keeps the first error, which Flush returns.
+	return nil
+}
+
+func (rw *runFileWriter) writeUvarint(n int) {
+	var buf [binary.MaxVarintLen64]byte
+	rw.w.Write(buf[:binary.PutUvarint(buf[:], uint64(n))])
+}
+
+func (rw *runFileWriter) Flush() error {
+	return rw.w.Flush()
+}
+
+// runFileReader reads the rows written by a runFileWriter.
+type runFileReader struct {
+	r *bufio.Reader
+}
+
+func newRunFileReader(r io.Reader) *runFileReader {
+	return &runFileReader{r: bufio.NewReader(r)}
+}
+
+func (rr *runFileReader) Read() ([]string, error) {
+	numFields, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, err
+	}
+	row := make([]string, numFields)
+	for i := range row {
+		length, err := binary.ReadUvarint(rr.r)
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		cell := make([]byte, length)
+		_, err = io.ReadFull(rr.r, cell)
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		row[i] = string(cell)
+	}
+	return row, nil
+}
+
+func writeRowsToFile(filename string, rows [][]string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := newRunFileWriter(file)
+	for _, row := range rows {
+		writer.Write(row)
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func readRowsFromFile(filename string) ([][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := newRunFileReader(file)
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
@@ -18,6 +18,7 @@ type FilterSubcommand struct {
 	gteStr          string
 	ltStr           string
 	lteStr          string
+	follow          bool
 }
 
 func (sub *FilterSubcommand) Name() string {
@@ -42,6 +43,7 @@ func (sub *FilterSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.StringVar(&sub.gteStr, "gte", "", "Greater than or equal to")
 	fs.StringVar(&sub.ltStr, "lt", "", "Less than")
 	fs.StringVar(&sub.lteStr, "lte", "", "Less than or equal to")
+	fs.BoolVar(&sub.follow, "follow", false, "Keep filtering rows as they are appended to the file")
 }
 
 func (sub *FilterSubcommand) Run(args []string) {
@@ -191,6 +193,14 @@ func (sub *FilterSubcommand) RunFilter(inputCsv *InputCsv, outputCsvWriter Outpu
 	} else {
 		ExitWithError(errors.New("Missing filter function"))
 	}
+
+	if sub.follow {
+		err := inputCsv.Follow(FOLLOW_POLL_INTERVAL)
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
+
 	FilterMatchFunc(inputCsv, outputCsvWriter, columns, sub.exclude, matchFunc)
 }
 
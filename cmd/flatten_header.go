@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+)
+
+type FlattenHeaderSubcommand struct {
+	numRows int
+	joiner  string
+}
+
+func (sub *FlattenHeaderSubcommand) Name() string {
+	return "flatten-header"
+}
+func (sub *FlattenHeaderSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *FlattenHeaderSubcommand) Description() string {
+	return "Flatten a header spanning multiple rows into one row."
+}
+func (sub *FlattenHeaderSubcommand) SetFlags(fs *flag.FlagSet) {
+	fs.IntVar(&sub.numRows, "rows", 2, "Number of rows in the header")
+	fs.StringVar(&sub.joiner, "joiner", " ", "String joining the names from each row of the header")
+}
+
+func (sub *FlattenHeaderSubcommand) Run(args []string) {
+	inputCsvs := GetInputCsvsOrPanic(args, 1)
+	outputCsv := NewOutputCsvFromInputCsv(inputCsvs[0])
+	// The flattened header is written even with --no-header, which only
+	// means that the rows of the header are read as data.
+	outputCsv.KeepHeader()
+	sub.RunFlattenHeader(inputCsvs[0], outputCsv)
+}
+
+func (sub *FlattenHeaderSubcommand) RunFlattenHeader(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter) {
+	if sub.numRows < 1 {
+		ExitWithError(errors.New("Invalid argument --rows"))
+	}
+
+	// The rows of the header may have different numbers of fields, for
+	// example if trailing empty cells are left out, so only check the
+	// number of fields of the rows after them.
+	fieldsPerRecord := inputCsv.Reader().FieldsPerRecord
+	inputCsv.SetFieldsPerRecord(-1)
+	if !inputCsv.HasHeader() {
+		_, err := inputCsv.Read()
+		if err != nil && err != io.EOF {
+			ExitWithError(err)
+		}
+	}
+	headerRows := make([][]string, 0, sub.numRows)
+	for len(headerRows) < sub.numRows {
+		row, err := inputCsv.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			ExitWithError(err)
+		}
+		headerRows = append(headerRows, row)
+	}
+	inputCsv.SetFieldsPerRecord(fieldsPerRecord)
+	if len(headerRows) == 0 {
+		return
+	}
+
+	outputCsvWriter.Write(FlattenHeader(headerRows, sub.joiner))
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		outputCsvWriter.Write(row)
+	}
+}
+
+// FlattenHeader joins the names in each column of a header spanning
+// multiple rows. An empty cell in any row but the last is taken to be
+// part of a cell merged with the one to its left, as long as the cells
+// above them are also merged, so it is filled with the name to its left.
+// Empty names are left out when joining.
+func FlattenHeader(headerRows [][]string, joiner string) []string {
+	numColumns := 0
+	for _, row := range headerRows {
+		if len(row) > numColumns {
+			numColumns = len(row)
+		}
+	}
+	filled := make([][]string, len(headerRows))
+	for i, row := range headerRows {
+		filled[i] = make([]string, numColumns)
+		copy(filled[i], row)
+		if i == len(headerRows)-1 {
+			break
+		}
+		for j := 1; j < numColumns; j++ {
+			if filled[i][j] == "" && (i == 0 || isMergedAbove(filled, i-1, j)) {
+				filled[i][j] = filled[i][j-1]
+			}
+		}
+	}
+
+	header := make([]string, numColumns)
+	for j := range header {
+		names := make([]string, 0, len(filled))
+		for i := range filled {
+			if filled[i][j] != "" {
+				names = append(names, filled[i][j])
+			}
+		}
+		header[j] = strings.Join(names, joiner)
+	}
+	return header
+}
+
+// isMergedAbove reports whether the cells in column j and the one to its
+// left have the same names in all of the rows up to row i.
+func isMergedAbove(filled [][]string, i, j int) bool {
+	for ; i >= 0; i-- {
+		if filled[i][j] != filled[i][j-1] {
+			return false
+		}
+	}
+	return true
+}
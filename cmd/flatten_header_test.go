@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFlattenHeader(t *testing.T) {
+	testCases := []struct {
+		headerRows [][]string
+		joiner     string
+		header     []string
+	}{
+		{[][]string{
+			{"Region", "Q1", "", "Q2", ""},
+			{"", "Revenue", "Cost", "Revenue", "Cost"},
+		}, " / ", []string{"Region", "Q1 / Revenue", "Q1 / Cost", "Q2 / Revenue", "Q2 / Cost"}},
+		{[][]string{
+			{"ID", "2021", "", "", ""},
+			{"", "H1", "", "H2"},
+			{"", "Jan", "Feb", "Jul", "Aug"},
+		}, "_", []string{"ID", "2021_H1_Jan", "2021_H1_Feb", "2021_H2_Jul", "2021_H2_Aug"}},
+		{[][]string{
+			{"A", "B", ""},
+			{"", "", "", "x"},
+		}, " ", []string{"A", "B", "B", "B x"}},
+		{[][]string{
+			{"Name", "Notes", ""},
+		}, " ", []string{"Name", "Notes", ""}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			header := FlattenHeader(tt.headerRows, tt.joiner)
+			err := assertRowsEqual([][]string{tt.header}, [][]string{header})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRunFlattenHeader(t *testing.T) {
+	ic, err := NewInputCsv("../test-files/multirow-header.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	toc := new(testOutputCsv)
+	sub := &FlattenHeaderSubcommand{numRows: 2, joiner: " / "}
+	sub.RunFlattenHeader(ic, toc)
+	err = assertRowsEqual([][]string{
+		{"Region", "Q1 / Revenue", "Q1 / Cost", "Q2 / Revenue", "Q2 / Cost"},
+		{"North", "100", "60", "120", "70"},
+		{"South", "80", "50", "90", "55"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunFlattenHeaderNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	ic, err := NewInputCsv("../test-files/multirow-header.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	toc := new(testOutputCsv)
+	sub := &FlattenHeaderSubcommand{numRows: 2, joiner: " / "}
+	sub.RunFlattenHeader(ic, toc)
+	err = assertRowsEqual([][]string{
+		{"Region", "Q1 / Revenue", "Q1 / Cost", "Q2 / Revenue", "Q2 / Cost"},
+		{"North", "100", "60", "120", "70"},
+		{"South", "80", "50", "90", "55"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FOLLOW_POLL_INTERVAL is how often a followed file is checked for new data.
+const FOLLOW_POLL_INTERVAL = 250 * time.Millisecond
+
+// FOLLOW_CHUNK_SIZE is the number of bytes read from a followed file at a time.
+const FOLLOW_CHUNK_SIZE = 32 * 1024
+
+// FollowReader reads a file like `tail -f`: rather than returning io.EOF at
+// the end of the file, it waits for more data to be appended. If the file is
+// truncated or replaced by a new file with the same name (e.g. by log
+// rotation), it starts reading the new contents from the beginning, skipping
+// the header row of the new file. Reading stops with io.EOF once the
+// FollowReader is closed.
+//
+// Only complete lines are returned, so that a row that is still being
+// written isn't read before its line terminator has been appended.
+type FollowReader struct {
+	filename     string
+	file         *os.File
+	offset       int64
+	pollInterval time.Duration
+
+	// Data read from the file but not yet returned, of which the first
+	// numComplete bytes end with a newline.
+	chunk       []byte
+	buf         []byte
+	numComplete int
+
+	// Until following is set, reading stops with io.EOF at the end of
+	// the last complete line rather than waiting for more data.
+	following bool
+
+	// State for skipping the header after a truncation or rotation,
+	// unless the file has no header.
+	noHeader       bool
+	skippingHeader bool
+	headerQuotes   int
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewFollowReader follows file, which was opened from filename, starting
+// at the byte offset.
+func NewFollowReader(filename string, file *os.File, offset int64, pollInterval time.Duration) (*FollowReader, error) {
+	_, err := file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	fr := &FollowReader{
+		filename:     filename,
+		file:         file,
+		offset:       offset,
+		pollInterval: pollInterval,
+		following:    true,
+		done:         make(chan struct{}),
+	}
+	return fr, nil
+}
+
+func (fr *FollowReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		if fr.numComplete > 0 {
+			n := copy(p, fr.buf[:fr.numComplete])
+			fr.buf = append(fr.buf[:0], fr.buf[n:]...)
+			fr.numComplete -= n
+			return n, nil
+		}
+
+		if fr.chunk == nil {
+			fr.chunk = make([]byte, FOLLOW_CHUNK_SIZE)
+		}
+		chunk := fr.chunk
+		n, err := fr.file.Read(chunk)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if n > 0 {
+			fr.offset += int64(n)
+			if fr.skippingHeader {
+				n = fr.skipHeader(chunk[:n])
+			}
+			fr.buf = append(fr.buf, chunk[:n]...)
+			fr.numComplete = bytes.LastIndexByte(fr.buf, '\n') + 1
+			continue
+		}
+
+		// At the end of the file, so check whether it has been
+		// truncated or rotated before waiting for more data.
+		reopened, err := fr.checkForNewFile()
+		if err != nil {
+			return 0, err
+		}
+		if reopened {
+			continue
+		}
+		if !fr.following {
+			return 0, io.EOF
+		}
+		select {
+		case <-fr.done:
+			return 0, io.EOF
+		case <-time.After(fr.pollInterval):
+		}
+	}
+}
+
+// SeekToOffset moves to a byte offset of the file, discarding any data read but
+// not yet returned.
+func (fr *FollowReader) SeekToOffset(offset int64) error {
+	_, err := fr.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	fr.offset = offset
+	fr.buf = fr.buf[:0]
+	fr.numComplete = 0
+	return nil
+}
+
+// Offset returns the byte offset in the file up to which data has been
+// returned.
+func (fr *FollowReader) Offset() int64 {
+	return fr.offset - int64(len(fr.buf))
+}
+
+// skipHeader drops the bytes of p up to and including the newline ending the
+// header row, moving any remaining bytes to the front of p. A newline only
+// ends the header if it is not within a quoted field.
+func (fr *FollowReader) skipHeader(p []byte) int {
+	for i, c := range p {
+		if c == '"' {
+			fr.headerQuotes++
+		} else if c == '\n' && fr.headerQuotes%2 == 0 {
+			fr.skippingHeader = false
+			return copy(p, p[i+1:])
+		}
+	}
+	return 0
+}
+
+func (fr *FollowReader) checkForNewFile() (bool, error) {
+	info, err := os.Stat(fr.filename)
+	if err != nil {
+		// The file may be missing briefly while it is being rotated.
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	curInfo, err := fr.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(info, curInfo) {
+		file, err := os.Open(fr.filename)
+		if err != nil {
+			return false, err
+		}
+		fr.file.Close()
+		fr.file = file
+		fr.restart()
+		return true, nil
+	}
+	if info.Size() < fr.offset {
+		_, err = fr.file.Seek(0, io.SeekStart)
+		if err != nil {
+			return false, err
+		}
+		fr.restart()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (fr *FollowReader) restart() {
+	fr.offset = 0
+	fr.buf = fr.buf[:0]
+	fr.numComplete = 0
+	fr.skippingHeader = !fr.noHeader
+	fr.headerQuotes = 0
+}
+
+// Close stops following the file. Any blocked or future calls to Read
+// return io.EOF once the data currently in the file has been read.
+func (fr *FollowReader) Close() error {
+	fr.closeOnce.Do(func() {
+		close(fr.done)
+	})
+	return nil
+}
+
+// File returns the file currently being followed, which may differ from
+// the original file after a rotation.
+func (fr *FollowReader) File() *os.File {
+	return fr.file
+}
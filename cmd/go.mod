@@ -1,5 +1,7 @@
 module github.com/DataFoxCo/gocsv/cmd
 
+go 1.16
+
 replace github.com/DataFoxCo/gocsv/csv => ../csv
 
 require (
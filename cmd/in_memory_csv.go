@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"math"
-	"math/rand"
 	"sort"
 	"strings"
 	"time"
@@ -97,102 +96,13 @@ func (imc *InMemoryCsv) InferType(columnIndex int) ColumnType {
 	return curType
 }
 
-func (imc *InMemoryCsv) SortRows(columnIndices []int, columnTypes []ColumnType, reverse bool) {
+// SortRows performs a stable sort of the rows on the sort columns.
+func (imc *InMemoryCsv) SortRows(sortColumns []SortColumn) {
 	isLessFunc := func(row1Ptr, row2Ptr *[]string) bool {
-		row1 := *row1Ptr
-		row2 := *row2Ptr
-		for i, columnIndex := range columnIndices {
-			isElem1Null := IsNullType(row1[columnIndex])
-			isElem2Null := IsNullType(row2[columnIndex])
-			if isElem1Null && isElem2Null {
-				continue
-			}
-			if isElem1Null && !isElem2Null {
-				return true
-			}
-			if !isElem1Null && isElem2Null {
-				return false
-			}
-			columnType := columnTypes[i]
-			if columnType == FLOAT_TYPE {
-				row1Val := ParseFloat64OrPanic(row1[columnIndex])
-				row2Val := ParseFloat64OrPanic(row2[columnIndex])
-				if row1Val < row2Val {
-					return true
-				} else if row1Val > row2Val {
-					return false
-				}
-			} else if columnType == INT_TYPE {
-				row1Val := ParseInt64OrPanic(row1[columnIndex])
-				row2Val := ParseInt64OrPanic(row2[columnIndex])
-				if row1Val < row2Val {
-					return true
-				} else if row1Val > row2Val {
-					return false
-				}
-			} else if columnType == DATETIME_TYPE {
-				row1Val := ParseDatetimeOrPanic(row1[columnIndex])
-				row2Val := ParseDatetimeOrPanic(row2[columnIndex])
-				if row1Val.Before(row2Val) {
-					return true
-				} else if row1Val.After(row2Val) {
-					return false
-				}
-			} else if columnType == DATE_TYPE {
-				_, row1Val := ParseDateOrPanic(row1[columnIndex])
-				_, row2Val := ParseDateOrPanic(row2[columnIndex])
-				if row1Val.Before(row2Val) {
-					return true
-				} else if row1Val.After(row2Val) {
-					return false
-				}
-			} else {
-				row1Val := row1[columnIndex]
-				row2Val := row2[columnIndex]
-				if row1Val < row2Val {
-					return true
-				} else if row1Val > row2Val {
-					return false
-				}
-			}
-		}
-		return true
+		return CompareRows(*row1Ptr, *row2Ptr, sortColumns) < 0
 	}
 
-	SortRowsBy(isLessFunc).Sort(imc.rows, reverse)
-}
-
-func (imc *InMemoryCsv) SampleRowIndicesWithReplacement(numRows, seed int) []int {
-	totalRows := imc.NumRows()
-	retval := make([]int, numRows)
-	for i := 0; i < numRows; i++ {
-		retval[i] = rand.Intn(totalRows)
-	}
-	return retval
-}
-
-func (imc *InMemoryCsv) SampleRowIndicesWithoutReplacement(numRows, seed int) []int {
-	totalRows := imc.NumRows()
-	permuted := rand.Perm(totalRows)
-	retval := make([]int, numRows)
-	for i := 0; i < numRows; i++ {
-		retval[i] = permuted[i]
-	}
-	return retval
-}
-
-func (imc *InMemoryCsv) SampleRowIndices(numRows int, replace bool, seed int) []int {
-	// NOTE: Updating global `rand` variable for the life of the proces...
-	if seed != 0 {
-		rand.Seed(int64(seed))
-	} else {
-		rand.Seed(time.Now().UTC().UnixNano())
-	}
-	if replace {
-		return imc.SampleRowIndicesWithReplacement(numRows, seed)
-	} else {
-		return imc.SampleRowIndicesWithoutReplacement(numRows, seed)
-	}
+	SortRowsBy(isLessFunc).Stable(imc.rows)
 }
 
 func (imc *InMemoryCsv) PrintStats() {
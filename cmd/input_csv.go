@@ -2,38 +2,143 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/DataFoxCo/gocsv/csv"
 )
 
 type InputCsv struct {
-	file      *os.File
-	filename  string
-	reader    *csv.Reader
-	bufReader *bufio.Reader
-	hasBom    bool
+	file         *os.File
+	filename     string
+	reader       *csv.Reader
+	bufReader    *bufio.Reader
+	hasBom       bool
+	follower     *FollowReader
+	compression  string
+	decompressor io.ReadCloser
+	member       *archiveMember
+	encoding     string
+
+	// With --no-header, the first row is returned after a header of
+	// column numbers.
+	noHeader      bool
+	hasReadHeader bool
+	firstRow      []string
+
+	// With --skip-footer, rows are read ahead so that the last ones can
+	// be left out.
+	skipFooter int
+	aheadRows  []readAheadRow
+}
+
+type readAheadRow struct {
+	row []string
+	err error
 }
 
+// NewInputCsv opens an input CSV in the encoding given by the common
+// --input-encoding flag.
 func NewInputCsv(filename string) (ic *InputCsv, err error) {
+	return NewInputCsvWithEncoding(filename, INPUT_ENCODING)
+}
+
+// NewInputCsvWithEncoding opens an input CSV in a character encoding,
+// which may be "auto" to detect it, transcoding it into UTF-8.
+func NewInputCsvWithEncoding(filename, encoding string) (ic *InputCsv, err error) {
 	ic = new(InputCsv)
 	ic.filename = filename
 	if filename == "-" {
 		ic.file = os.Stdin
+		ic.bufReader = bufio.NewReaderSize(ic.file, SNIFF_SAMPLE_SIZE)
+	} else if archiveFilename, member, ok := SplitArchiveMember(filename); ok {
+		ic.member, err = OpenArchiveMember(archiveFilename, member)
+		if err != nil {
+			return
+		}
+		ic.bufReader = bufio.NewReaderSize(ic.member, SNIFF_SAMPLE_SIZE)
 	} else {
 		ic.file, err = os.Open(filename)
 		if err != nil {
 			return
 		}
+		ic.bufReader = bufio.NewReaderSize(ic.file, SNIFF_SAMPLE_SIZE)
+	}
+	err = ic.handleCompression()
+	if err != nil {
+		return
+	}
+	err = ic.handleEncoding(encoding)
+	if err != nil {
+		return
 	}
-	ic.bufReader = bufio.NewReader(ic.file)
 	ic.reader = csv.NewReader(ic.bufReader)
 	err = ic.handleBom()
+	if err != nil {
+		return
+	}
+	err = ic.skipLines()
+	if err != nil {
+		return
+	}
+	err = ic.handleDialect()
+	if err != nil {
+		return
+	}
+	err = ic.handlePreamble()
 	return
 }
 
+// handleCompression transparently decompresses input that is compressed
+// with gzip, bzip2 or zlib, as indicated by the filename extension or
+// detected from the magic bytes at the start of the input.
+func (ic *InputCsv) handleCompression() (err error) {
+	ic.compression = COMPRESSION_NONE
+	if ic.filename != "-" {
+		ic.compression = CompressionFromFilename(ic.filename)
+	}
+	if ic.compression == COMPRESSION_NONE {
+		ic.compression = DetectCompression(ic.bufReader)
+	}
+	if ic.compression == COMPRESSION_NONE {
+		return nil
+	}
+	ic.decompressor, err = NewDecompressingReader(ic.bufReader, ic.compression)
+	if err != nil {
+		return
+	}
+	ic.bufReader = bufio.NewReaderSize(ic.decompressor, SNIFF_SAMPLE_SIZE)
+	return nil
+}
+
+// handleEncoding transcodes input that is not in UTF-8 into UTF-8, so
+// that a byte order mark in UTF-16 is handled like the one in UTF-8.
+func (ic *InputCsv) handleEncoding(encoding string) (err error) {
+	ic.encoding, err = ParseEncoding(encoding)
+	if err != nil {
+		return
+	}
+	if ic.encoding == ENCODING_AUTO {
+		ic.encoding = DetectEncoding(ic.bufReader)
+	}
+	if ic.encoding != ENCODING_UTF8 {
+		ic.bufReader = bufio.NewReaderSize(NewDecodingReader(ic.bufReader, ic.encoding), SNIFF_SAMPLE_SIZE)
+	}
+	return nil
+}
+
+// Encoding returns the character encoding of the input, as specified or
+// detected.
+func (ic *InputCsv) Encoding() string {
+	return ic.encoding
+}
+
 func (ic *InputCsv) handleBom() error {
 	bomRune, _, err := ic.bufReader.ReadRune()
 	if err != nil && err != io.EOF {
@@ -47,7 +152,202 @@ func (ic *InputCsv) handleBom() error {
 	return nil
 }
 
+// handleDialect applies the common input flags to the CSV reader,
+// detecting the delimiter and quote character from the start of the input
+// after any preamble if the delimiter is "auto".
+func (ic *InputCsv) handleDialect() error {
+	if INPUT_DELIMITER == DELIMITER_AUTO {
+		dialect, err := ic.sniffAfterPreamble()
+		if err != nil {
+			return err
+		}
+		ic.SetDelimiter(dialect.Delimiter)
+		ic.reader.Quote = dialect.Quote
+	} else if INPUT_DELIMITER != "" {
+		delimiter, err := ParseMultiCharDelimiter(INPUT_DELIMITER)
+		if err != nil {
+			return err
+		}
+		ic.SetDelimiterString(delimiter)
+	}
+	if INPUT_QUOTE == QUOTE_NONE {
+		ic.reader.Quote = 0
+	} else if INPUT_QUOTE != "" {
+		quote, err := ParseDelimiter(INPUT_QUOTE)
+		if err != nil {
+			return err
+		}
+		ic.reader.Quote = quote
+	}
+	if INPUT_ESCAPE != "" {
+		escape, err := ParseDelimiter(INPUT_ESCAPE)
+		if err != nil {
+			return err
+		}
+		ic.reader.Escape = escape
+	}
+	if COMMENT != "" {
+		comment, err := ParseDelimiter(COMMENT)
+		if err != nil {
+			return err
+		}
+		ic.reader.Comment = comment
+	}
+	if LAZY_QUOTES {
+		ic.SetLazyQuotes(true)
+	}
+	if TRIM_LEADING_SPACE {
+		ic.reader.TrimLeadingSpace = true
+	}
+	if RAGGED {
+		ic.SetFieldsPerRecord(-1)
+	}
+	ic.noHeader = NO_HEADER
+	return nil
+}
+
+// skipLines skips the lines at the start of the input given by the common
+// --skip-lines flag, before the dialect of the input is detected.
+func (ic *InputCsv) skipLines() error {
+	for i := 0; i < SKIP_LINES; i++ {
+		_, err := ic.bufReader.ReadString('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sniffAfterPreamble detects the dialect of the input from the rows after
+// those skipped with --header-row or --auto-header, so that a preamble such
+// as a title doesn't throw off the detection. As the rows can't be parsed
+// before the dialect is known, each of them is taken to be a single line.
+// With --auto-header, the header is first found using the dialect detected
+// from the start of the input.
+func (ic *InputCsv) sniffAfterPreamble() (Dialect, error) {
+	numPreambleLines := 0
+	if HEADER_ROW > 0 {
+		numPreambleLines = HEADER_ROW - 1
+	} else if AUTO_HEADER {
+		dialect, err := SniffInputCsv(ic)
+		if err != nil {
+			return dialect, err
+		}
+		ic.SetDelimiter(dialect.Delimiter)
+		ic.reader.Quote = dialect.Quote
+		fieldCounts, err := ic.peekFieldCounts()
+		if err != nil {
+			return dialect, err
+		}
+		numPreambleLines = FindHeaderRow(fieldCounts)
+	}
+	return SniffInputCsvAfterLines(ic, numPreambleLines)
+}
+
+// handlePreamble skips any rows before the header given by the common
+// --header-row and --auto-header flags, and sets up skipping rows at the
+// end of the input for --skip-footer.
+func (ic *InputCsv) handlePreamble() error {
+	numRowsToSkip := 0
+	if HEADER_ROW > 0 {
+		numRowsToSkip = HEADER_ROW - 1
+	} else if AUTO_HEADER {
+		fieldCounts, err := ic.peekFieldCounts()
+		if err != nil {
+			return err
+		}
+		numRowsToSkip = FindHeaderRow(fieldCounts)
+	}
+	err := ic.skipRows(numRowsToSkip)
+	if err != nil {
+		return err
+	}
+	ic.skipFooter = SKIP_FOOTER
+	return nil
+}
+
+// skipRows reads and discards rows, whatever their number of fields and
+// quoting, so that the header sets the number of fields of later rows.
+func (ic *InputCsv) skipRows(numRows int) error {
+	fieldsPerRecord, lazyQuotes := ic.reader.FieldsPerRecord, ic.reader.LazyQuotes
+	ic.reader.FieldsPerRecord, ic.reader.LazyQuotes = -1, true
+	defer func() {
+		ic.reader.FieldsPerRecord, ic.reader.LazyQuotes = fieldsPerRecord, lazyQuotes
+	}()
+	for i := 0; i < numRows; i++ {
+		_, err := ic.reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// peekFieldCounts counts the fields of the rows at the start of the input
+// that fit in its buffer, without consuming them.
+func (ic *InputCsv) peekFieldCounts() ([]int, error) {
+	sample, err := ic.bufReader.Peek(ic.bufReader.Size())
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	truncated := err == nil
+	reader := csv.NewReader(bytes.NewReader(sample))
+	copyReaderSettings(reader, ic.reader)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = false
+	fieldCounts := make([]int, 0)
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			break
+		}
+		fieldCounts = append(fieldCounts, len(row))
+	}
+	// The last row may be cut short.
+	if truncated && len(fieldCounts) > 1 {
+		fieldCounts = fieldCounts[:len(fieldCounts)-1]
+	}
+	return fieldCounts, nil
+}
+
+// FindHeaderRow returns the index of the first row with the most common
+// number of fields, preferring more fields if several numbers are equally
+// common, which is taken to be the header below a preamble of titles and
+// notes.
+func FindHeaderRow(fieldCounts []int) int {
+	frequencies := make(map[int]int)
+	dominantCount := 0
+	for _, count := range fieldCounts {
+		frequencies[count]++
+		if frequencies[count] > frequencies[dominantCount] ||
+			(frequencies[count] == frequencies[dominantCount] && count > dominantCount) {
+			dominantCount = count
+		}
+	}
+	for i, count := range fieldCounts {
+		if count == dominantCount {
+			return i
+		}
+	}
+	return 0
+}
+
 func (ic *InputCsv) Close() error {
+	if ic.decompressor != nil {
+		ic.decompressor.Close()
+	}
+	if ic.follower != nil {
+		ic.follower.Close()
+		return ic.follower.File().Close()
+	}
+	if ic.member != nil {
+		return ic.member.Close()
+	}
 	return ic.file.Close()
 }
 
@@ -61,6 +361,151 @@ func (ic *InputCsv) SetLazyQuotes(lazyQuotes bool) {
 
 func (ic *InputCsv) SetDelimiter(delimiter rune) {
 	ic.reader.Comma = delimiter
+	ic.reader.Delimiter = ""
+}
+
+// SetDelimiterString sets a delimiter that may be more than one
+// character long.
+func (ic *InputCsv) SetDelimiterString(delimiter string) {
+	if utf8.RuneCountInString(delimiter) == 1 {
+		r, _ := utf8.DecodeRuneInString(delimiter)
+		ic.SetDelimiter(r)
+	} else {
+		ic.reader.Delimiter = delimiter
+	}
+}
+
+// IsRegularFile reports whether the input is an uncompressed regular file
+// in UTF-8, as opposed to standard input, a pipe, a compressed or
+// transcoded file or a member of an archive, and so supports seeking.
+func (ic *InputCsv) IsRegularFile() bool {
+	if ic.filename == "-" || ic.compression != COMPRESSION_NONE || ic.file == nil || ic.encoding != ENCODING_UTF8 {
+		return false
+	}
+	info, err := ic.file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode().IsRegular()
+}
+
+// File returns the underlying file of the input.
+func (ic *InputCsv) File() *os.File {
+	return ic.file
+}
+
+// SeekToOffset moves the input to a byte offset of the underlying file, discarding
+// any buffered input. The settings of the CSV reader are preserved. The
+// offset must be at the start of a record.
+func (ic *InputCsv) SeekToOffset(offset int64) error {
+	if ic.follower != nil {
+		err := ic.follower.SeekToOffset(offset)
+		if err != nil {
+			return err
+		}
+		ic.resetReader(ic.follower)
+		return nil
+	}
+	_, err := ic.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	ic.resetReader(ic.file)
+	return nil
+}
+
+// Reopen opens the input file again from the start, with the same
+// settings for the CSV reader. Standard input cannot be reopened.
+func (ic *InputCsv) Reopen() (*InputCsv, error) {
+	if ic.filename == "-" {
+		return nil, errors.New("Cannot reopen standard input")
+	}
+	reopened, err := NewInputCsvWithEncoding(ic.filename, ic.encoding)
+	if err != nil {
+		return nil, err
+	}
+	copyReaderSettings(reopened.reader, ic.reader)
+	return reopened, nil
+}
+
+// Offset returns the byte offset in the underlying file up to which the
+// input has been read, not including buffered input.
+func (ic *InputCsv) Offset() (int64, error) {
+	if ic.follower != nil {
+		return ic.follower.Offset() - int64(ic.bufReader.Buffered()), nil
+	}
+	fileOffset, err := ic.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return fileOffset - int64(ic.bufReader.Buffered()), nil
+}
+
+// Follow makes the input keep reading as the file grows, like `tail -f`,
+// continuing from the current position. Once following, reads block
+// waiting for new rows rather than returning io.EOF, and truncated or
+// rotated files are read again from their first row. Only regular files
+// can be followed.
+func (ic *InputCsv) Follow(pollInterval time.Duration) error {
+	if ic.follower != nil {
+		ic.follower.following = true
+		return nil
+	}
+	err := ic.PrepareToFollow(pollInterval)
+	if err != nil {
+		return err
+	}
+	ic.follower.following = true
+	return nil
+}
+
+// PrepareToFollow makes the input read only complete lines, stopping with
+// io.EOF at the end of the last one until Follow is called. This way the
+// rows read before following don't include a row whose line is still being
+// written at the end of the file.
+func (ic *InputCsv) PrepareToFollow(pollInterval time.Duration) error {
+	if !ic.IsRegularFile() {
+		return errors.New("Can only follow regular files")
+	}
+	if ic.skipFooter > 0 {
+		return errors.New("Cannot follow a file with --skip-footer")
+	}
+	offset, err := ic.Offset()
+	if err != nil {
+		return err
+	}
+	follower, err := NewFollowReader(ic.filename, ic.file, offset, pollInterval)
+	if err != nil {
+		return err
+	}
+	follower.following = false
+	follower.noHeader = ic.noHeader
+	ic.follower = follower
+	ic.resetReader(follower)
+	return nil
+}
+
+// resetReader replaces the source of the input, discarding any buffered
+// input while preserving the settings of the CSV reader.
+func (ic *InputCsv) resetReader(r io.Reader) {
+	ic.firstRow = nil
+	ic.aheadRows = nil
+	ic.bufReader.Reset(r)
+	reader := csv.NewReader(ic.bufReader)
+	copyReaderSettings(reader, ic.reader)
+	ic.reader = reader
+}
+
+func copyReaderSettings(dst, src *csv.Reader) {
+	dst.Comma = src.Comma
+	dst.Delimiter = src.Delimiter
+	dst.Quote = src.Quote
+	dst.Escape = src.Escape
+	dst.Comment = src.Comment
+	dst.FieldsPerRecord = src.FieldsPerRecord
+	dst.LazyQuotes = src.LazyQuotes
+	dst.TrimLeadingSpace = src.TrimLeadingSpace
+	dst.ReuseRecord = src.ReuseRecord
 }
 
 func (ic *InputCsv) Reader() *csv.Reader {
@@ -68,18 +513,86 @@ func (ic *InputCsv) Reader() *csv.Reader {
 }
 
 func (ic *InputCsv) Read() (row []string, err error) {
-	return ic.reader.Read()
+	if ic.noHeader && !ic.hasReadHeader {
+		return ic.readSyntheticHeader()
+	}
+	if ic.firstRow != nil {
+		row = ic.firstRow
+		ic.firstRow = nil
+		return
+	}
+	return ic.readRow()
+}
+
+// readRow reads the next row, leaving out the last rows with --skip-footer.
+// Errors, such as the wrong number of fields, are only returned with the
+// rows they belong to, so that they do not occur for skipped rows.
+func (ic *InputCsv) readRow() ([]string, error) {
+	if ic.skipFooter == 0 {
+		return ic.reader.Read()
+	}
+	for len(ic.aheadRows) <= ic.skipFooter {
+		row, err := ic.reader.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+		if ic.reader.ReuseRecord && row != nil {
+			row = append([]string(nil), row...)
+		}
+		ic.aheadRows = append(ic.aheadRows, readAheadRow{row, err})
+	}
+	next := ic.aheadRows[0]
+	ic.aheadRows = ic.aheadRows[1:]
+	return next.row, next.err
+}
+
+// readSyntheticHeader reads the first row of an input without a header,
+// keeping it to be read next, and returns a header naming the columns
+// 1, 2, 3 and so on, so that they can be specified by index or by name.
+func (ic *InputCsv) readSyntheticHeader() (header []string, err error) {
+	firstRow, err := ic.readRow()
+	if err != nil {
+		return
+	}
+	ic.hasReadHeader = true
+	ic.firstRow = make([]string, len(firstRow))
+	copy(ic.firstRow, firstRow)
+	header = make([]string, len(firstRow))
+	for i := range header {
+		header[i] = strconv.Itoa(i + 1)
+	}
+	return
+}
+
+// HasHeader reports whether the first row of the input is a header, as
+// opposed to one synthesized with --no-header.
+func (ic *InputCsv) HasHeader() bool {
+	return !ic.noHeader
 }
 
 func (ic *InputCsv) ReadAll() (rows [][]string, err error) {
-	return ic.reader.ReadAll()
+	if !ic.noHeader && ic.skipFooter == 0 {
+		return ic.reader.ReadAll()
+	}
+	for {
+		row, err := ic.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
 }
 
 func (ic *InputCsv) Name() string {
 	if ic.filename == "-" {
 		return "stdin"
+	} else if _, member, ok := SplitArchiveMember(ic.filename); ok {
+		return GetBaseFilenameWithoutExtension(TrimCompressionExtension(member))
 	} else {
-		return GetBaseFilenameWithoutExtension(ic.filename)
+		return GetBaseFilenameWithoutExtension(TrimCompressionExtension(ic.filename))
 	}
 }
 
@@ -95,7 +608,17 @@ func GetInputCsvsOrPanic(filenames []string, numInputCsvs int) (csvs []*InputCsv
 	return
 }
 
+// GetInputCsvs opens the input CSVs of a command taking numInputCsvs
+// inputs, or any number of inputs if numInputCsvs is -1, reading from
+// standard input if one input is missing. The filename of a ZIP archive
+// expands into all of the CSVs within it, so commands taking a fixed
+// number of inputs need archives with more than one CSV to be given as
+// "archive.zip#member".
 func GetInputCsvs(filenames []string, numInputCsvs int) (csvs []*InputCsv, err error) {
+	filenames, err = expandArchives(filenames, numInputCsvs)
+	if err != nil {
+		return
+	}
 	hasDash := false
 	for _, filename := range filenames {
 		if filename == "-" {
@@ -154,3 +677,18 @@ func GetInputCsvs(filenames []string, numInputCsvs int) (csvs []*InputCsv, err e
 		return
 	}
 }
+
+func expandArchives(filenames []string, numInputCsvs int) ([]string, error) {
+	expanded := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		archiveFilenames, err := ExpandArchive(filename)
+		if err != nil {
+			return nil, err
+		}
+		if numInputCsvs != -1 && len(archiveFilenames) > 1 {
+			return nil, fmt.Errorf("Archive %s contains %d CSVs, specify one as %s", filename, len(archiveFilenames), ArchiveMemberFilename(filename, "path/in/zip.csv"))
+		}
+		expanded = append(expanded, archiveFilenames...)
+	}
+	return expanded, nil
+}
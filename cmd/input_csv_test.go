@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -97,3 +101,211 @@ func TestGetInputCsvsErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestInputCsvCommonFlags(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-input-csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "dialect.csv")
+	err = ioutil.WriteFile(filename, []byte("# Exported data\nName| Notes\nAlice| said \"hi\"\nBob\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	INPUT_DELIMITER, COMMENT, LAZY_QUOTES, TRIM_LEADING_SPACE, RAGGED = "|", "#", true, true, true
+	defer func() {
+		INPUT_DELIMITER, COMMENT, LAZY_QUOTES, TRIM_LEADING_SPACE, RAGGED = "", "", false, false, false
+	}()
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	rows, err := ic.ReadAll()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err = assertRowsEqual([][]string{
+		{"Name", "Notes"},
+		{"Alice", "said \"hi\""},
+		{"Bob"},
+	}, rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInputCsvQuoteAndEscape(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-input-csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "outfile.txt")
+	err = ioutil.WriteFile(filename, []byte("id\tname\tnote\n1\t\"Bob\t\\N\n2\tit\\'s\tline\\\nbreak\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		quote    string
+		expected [][]string
+	}{
+		{QUOTE_NONE, [][]string{
+			{"id", "name", "note"},
+			{"1", "\"Bob", "N"},
+			{"2", "it's", "line\nbreak"},
+		}},
+		{"'", [][]string{
+			{"id", "name", "note"},
+			{"1", "\"Bob", "N"},
+			{"2", "it's", "line\nbreak"},
+		}},
+	}
+	INPUT_DELIMITER, INPUT_ESCAPE = "\\t", "\\"
+	defer func() {
+		INPUT_DELIMITER, INPUT_QUOTE, INPUT_ESCAPE = "", "", ""
+	}()
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			INPUT_QUOTE = tt.quote
+			ic, err := NewInputCsv(filename)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			defer ic.Close()
+			rows, err := ic.ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			err = assertRowsEqual(tt.expected, rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestInputCsvNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	if ic.HasHeader() {
+		t.Error("Expected no header")
+	}
+	rows, err := ic.ReadAll()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err = assertRowsEqual([][]string{
+		{"1", "2"},
+		{"Number", "String"},
+		{"1", "One"},
+		{"2", "Two"},
+		{"-1", "Minus One"},
+		{"2", "Another Two"},
+	}, rows)
+	if err != nil {
+		t.Error(err)
+	}
+	indices, err := GetIndicesForColumns(rows[0], []string{"2"})
+	if err != nil || len(indices) != 1 || indices[0] != 1 {
+		t.Errorf("Expected column 2 at index 1 but got %v (%v)", indices, err)
+	}
+}
+
+func TestInputCsvPreambleAndFooter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-input-csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "report.csv")
+	contents := "Account Statement\n\"Period: Jan, \"\"2021\"\"\"\n\nDate,Description,Amount\n2021-01-04,Coffee,-2.50\n2021-01-05,Salary,1000.00\nTotal,,997.50\n"
+	err = ioutil.WriteFile(filename, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"Date", "Description", "Amount"},
+		{"2021-01-04", "Coffee", "-2.50"},
+		{"2021-01-05", "Salary", "1000.00"},
+	}
+	testCases := []struct {
+		skipLines  int
+		headerRow  int
+		autoHeader bool
+		skipFooter int
+		rows       [][]string
+	}{
+		{3, 0, false, 1, expected},
+		{0, 4, false, 1, expected},
+		{1, 3, false, 1, expected},
+		{0, 0, true, 1, expected},
+		{0, 0, true, 0, append(expected, []string{"Total", "", "997.50"})},
+		{0, 4, false, 10, nil},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			SKIP_LINES, HEADER_ROW, AUTO_HEADER, SKIP_FOOTER = tt.skipLines, tt.headerRow, tt.autoHeader, tt.skipFooter
+			defer func() {
+				SKIP_LINES, HEADER_ROW, AUTO_HEADER, SKIP_FOOTER = 0, 0, false, 0
+			}()
+			ic, err := NewInputCsv(filename)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			defer ic.Close()
+			rows, err := ic.ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			err = assertRowsEqual(tt.rows, rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	// The last rows of a regular file must not include the skipped lines.
+	SKIP_LINES = 3
+	defer func() { SKIP_LINES = 0 }()
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	toc := new(testOutputCsv)
+	TailFromBottom(ic, toc, 4)
+	err = assertRowsEqual(append(expected, []string{"Total", "", "997.50"}), toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFindHeaderRow(t *testing.T) {
+	testCases := []struct {
+		fieldCounts []int
+		headerRow   int
+	}{
+		{[]int{}, 0},
+		{[]int{3, 3, 3}, 0},
+		{[]int{1, 1, 4, 4, 4, 2}, 2},
+		{[]int{1, 2, 2, 3, 3}, 3},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			headerRow := FindHeaderRow(tt.fieldCounts)
+			if headerRow != tt.headerRow {
+				t.Errorf("Expected %d but got %d", tt.headerRow, headerRow)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InputFilesFlags are the flags shared by subcommands that take any number
+// of input files, for specifying the files other than by literal filenames.
+type InputFilesFlags struct {
+	filesFrom string
+	recursive bool
+}
+
+func (f *InputFilesFlags) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.filesFrom, "files-from", "", "File listing input files, one per line")
+	fs.BoolVar(&f.recursive, "recursive", false, "Include CSVs in subdirectories of directories")
+}
+
+// ExpandFilenamesOrPanic is a simple wrapper around ExpandFilenames
+// that will simply panic if ExpandFilenames returns an error.
+func (f *InputFilesFlags) ExpandFilenamesOrPanic(args []string) []string {
+	filenames, err := f.ExpandFilenames(args)
+	if err != nil {
+		ExitWithError(err)
+	}
+	return filenames
+}
+
+// ExpandFilenames expands the arguments, followed by the filenames listed
+// in the --files-from file, into the input filenames.
+func (f *InputFilesFlags) ExpandFilenames(args []string) ([]string, error) {
+	if f.filesFrom != "" {
+		listed, err := ReadFilenamesFrom(f.filesFrom)
+		if err != nil {
+			return nil, err
+		}
+		args = append(append([]string{}, args...), listed...)
+	}
+	return ExpandFilenames(args, f.recursive)
+}
+
+// ReadFilenamesFrom reads a list of filenames, one per line, ignoring blank
+// lines. The list is read from standard input if listFilename is "-".
+func ReadFilenamesFrom(listFilename string) ([]string, error) {
+	var file *os.File
+	if listFilename == "-" {
+		file = os.Stdin
+	} else {
+		var err error
+		file, err = os.Open(listFilename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+	}
+	filenames := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		filename := strings.TrimSpace(scanner.Text())
+		if filename != "" {
+			filenames = append(filenames, filename)
+		}
+	}
+	return filenames, scanner.Err()
+}
+
+// ExpandFilenames expands glob patterns and directories into the filenames
+// they contain, keeping other filenames (including "-" for standard input)
+// as they are. An argument is only treated as a pattern if no file exists
+// with that name. Patterns support "**" to match any number of directories,
+// and directories expand into the CSVs within them, including those in
+// subdirectories if recursive is true. The filenames matching each pattern
+// or within each directory are sorted.
+func ExpandFilenames(args []string, recursive bool) ([]string, error) {
+	filenames := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "-" {
+			filenames = append(filenames, arg)
+			continue
+		}
+		// A file whose name looks like a pattern, such as
+		// "report[2024].csv", is used as it is.
+		info, err := os.Stat(arg)
+		if err != nil && isGlobPattern(arg) {
+			matches, err := Glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, errors.New("No files match pattern " + arg)
+			}
+			filenames = append(filenames, matches...)
+			continue
+		}
+		if err == nil && info.IsDir() {
+			csvFilenames, err := FindCsvsInDirectory(arg, recursive)
+			if err != nil {
+				return nil, err
+			}
+			filenames = append(filenames, csvFilenames...)
+			continue
+		}
+		filenames = append(filenames, arg)
+	}
+	return filenames, nil
+}
+
+func isGlobPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// IsCsvFilename reports whether a filename has a CSV extension, possibly
+// followed by the extension of a compression format.
+func IsCsvFilename(filename string) bool {
+	return strings.EqualFold(filepath.Ext(TrimCompressionExtension(filename)), ".csv")
+}
+
+// FindCsvsInDirectory returns the sorted filenames of the CSVs in a
+// directory, and in its subdirectories if recursive is true.
+func FindCsvsInDirectory(dirname string, recursive bool) ([]string, error) {
+	filenames := make([]string, 0)
+	if !recursive {
+		infos, err := ioutil.ReadDir(dirname)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if !info.IsDir() && IsCsvFilename(info.Name()) {
+				filenames = append(filenames, filepath.Join(dirname, info.Name()))
+			}
+		}
+		return filenames, nil
+	}
+	err := filepath.Walk(dirname, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && IsCsvFilename(path) {
+			filenames = append(filenames, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filenames)
+	return filenames, nil
+}
+
+// Glob returns the sorted names of the files matching a pattern. It is
+// like filepath.Glob, except that a "**" path component matches zero or
+// more directories, and only files (not directories) are matched.
+func Glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files := make([]string, 0, len(matches))
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+		return files, nil
+	}
+
+	// Walk from the directory before the first component with a pattern.
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	numRootParts := 0
+	for numRootParts < len(parts) && !isGlobPattern(parts[numRootParts]) {
+		numRootParts++
+	}
+	root := strings.Join(parts[:numRootParts], "/")
+	patternParts := parts[numRootParts:]
+	if root == "" && numRootParts > 0 {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+	for _, part := range patternParts {
+		if _, err := filepath.Match(part, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	matches := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if matchPathParts(patternParts, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchPathParts matches the components of a path against the components
+// of a pattern, where "**" matches any number of components.
+func matchPathParts(patternParts, pathParts []string) bool {
+	if len(patternParts) == 0 {
+		return len(pathParts) == 0
+	}
+	if patternParts[0] == "**" {
+		for i := 0; i <= len(pathParts); i++ {
+			if matchPathParts(patternParts[1:], pathParts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pathParts) == 0 {
+		return false
+	}
+	matched, _ := filepath.Match(patternParts[0], pathParts[0])
+	return matched && matchPathParts(patternParts[1:], pathParts[1:])
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandFilenames(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-input-files-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, filename := range []string{
+		"a.csv",
+		"b.CSV",
+		"notes.txt",
+		"report[2024].csv",
+		"2021/jan.csv",
+		"2021/feb.csv",
+		"2021/q1/mar.csv",
+		"2022/jan.csv",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(filename))
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte("A\n1\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	listFilename := filepath.Join(tmpDir, "list.txt")
+	err = ioutil.WriteFile(listFilename, []byte(filepath.Join(tmpDir, "a.csv")+"\n\n"+filepath.Join(tmpDir, "2022")+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		args      []string
+		filesFrom string
+		recursive bool
+		filenames []string
+	}{
+		{[]string{"-", "x.csv"}, "", false, []string{"-", "x.csv"}},
+		{[]string{"*.csv"}, "", false, []string{"a.csv", "report[2024].csv"}},
+		{[]string{"*/*.csv"}, "", false, []string{"2021/feb.csv", "2021/jan.csv", "2022/jan.csv"}},
+		{[]string{"**/*.csv"}, "", false, []string{"2021/feb.csv", "2021/jan.csv", "2021/q1/mar.csv", "2022/jan.csv", "a.csv", "report[2024].csv"}},
+		{[]string{"2021/**/*.csv", "a.csv"}, "", false, []string{"2021/feb.csv", "2021/jan.csv", "2021/q1/mar.csv", "a.csv"}},
+		{[]string{"**/jan.csv"}, "", false, []string{"2021/jan.csv", "2022/jan.csv"}},
+		{[]string{"."}, "", false, []string{"a.csv", "b.CSV", "report[2024].csv"}},
+		// An existing file is not treated as a pattern.
+		{[]string{"report[2024].csv"}, "", false, []string{"report[2024].csv"}},
+		{[]string{"2021"}, "", true, []string{"2021/feb.csv", "2021/jan.csv", "2021/q1/mar.csv"}},
+		{[]string{"b.CSV"}, listFilename, false, []string{"b.CSV", "a.csv", "2022/jan.csv"}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			args := make([]string, len(tt.args))
+			for j, arg := range tt.args {
+				if arg == "-" || arg == "x.csv" {
+					args[j] = arg
+				} else {
+					args[j] = filepath.Join(tmpDir, filepath.FromSlash(arg))
+				}
+			}
+			f := InputFilesFlags{filesFrom: tt.filesFrom, recursive: tt.recursive}
+			filenames, err := f.ExpandFilenames(args)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			for j, filename := range filenames {
+				if rel, err := filepath.Rel(tmpDir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+					filenames[j] = filepath.ToSlash(rel)
+				}
+			}
+			if strings.Join(filenames, ",") != strings.Join(tt.filenames, ",") {
+				t.Errorf("Expected %v but got %v", tt.filenames, filenames)
+			}
+		})
+	}
+
+	_, err = ExpandFilenames([]string{filepath.Join(tmpDir, "*.json")}, false)
+	if err == nil {
+		t.Error("Expected error for pattern without matches")
+	}
+}
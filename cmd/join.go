@@ -12,6 +12,7 @@ type JoinSubcommand struct {
 	left          bool
 	right         bool
 	outer         bool
+	inputFiles    InputFilesFlags
 }
 
 func (sub *JoinSubcommand) Name() string {
@@ -29,6 +30,7 @@ func (sub *JoinSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&sub.left, "left", false, "Left join")
 	fs.BoolVar(&sub.right, "right", false, "Right join")
 	fs.BoolVar(&sub.outer, "outer", false, "Full outer join")
+	sub.inputFiles.SetFlags(fs)
 }
 
 func (sub *JoinSubcommand) Run(args []string) {
@@ -59,20 +61,21 @@ func (sub *JoinSubcommand) Run(args []string) {
 		columns = append(columns, columns[0])
 	}
 
-	inputCsvs := GetInputCsvsOrPanic(args, 2)
+	inputCsvs := GetInputCsvsOrPanic(sub.inputFiles.ExpandFilenamesOrPanic(args), 2)
+	outputCsv := NewOutputCsvFromInputCsvs(inputCsvs)
 
 	if sub.left {
-		LeftJoin(inputCsvs[0], inputCsvs[1], columns[0], columns[1])
+		LeftJoin(inputCsvs[0], inputCsvs[1], outputCsv, columns[0], columns[1])
 	} else if sub.right {
-		RightJoin(inputCsvs[0], inputCsvs[1], columns[0], columns[1])
+		RightJoin(inputCsvs[0], inputCsvs[1], outputCsv, columns[0], columns[1])
 	} else if sub.outer {
-		OuterJoin(inputCsvs[0], inputCsvs[1], columns[0], columns[1])
+		OuterJoin(inputCsvs[0], inputCsvs[1], outputCsv, columns[0], columns[1])
 	} else {
-		InnerJoin(inputCsvs[0], inputCsvs[1], columns[0], columns[1])
+		InnerJoin(inputCsvs[0], inputCsvs[1], outputCsv, columns[0], columns[1])
 	}
 }
 
-func InnerJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname string) {
+func InnerJoin(leftInputCsv, rightInputCsv *InputCsv, outputCsvWriter OutputCsvWriter, leftColname, rightColname string) {
 	leftHeader, err := leftInputCsv.Read()
 	if err != nil {
 		ExitWithError(err)
@@ -87,11 +90,9 @@ func InnerJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 
 	shellRow := make([]string, numLeftColumns+numRightColumns)
 
-	outputCsv := NewOutputCsvFromInputCsvs([]*InputCsv{leftInputCsv, rightInputCsv})
-
 	// Write header.
 	concat(shellRow, leftHeader, rightCsv.header)
-	outputCsv.Write(shellRow)
+	outputCsvWriter.Write(shellRow)
 
 	// Write inner-joined rows.
 	for {
@@ -107,13 +108,13 @@ func InnerJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 		if len(rightRows) > 0 {
 			for _, rightRow := range rightRows {
 				concat(shellRow, row, rightRow)
-				outputCsv.Write(shellRow)
+				outputCsvWriter.Write(shellRow)
 			}
 		}
 	}
 }
 
-func LeftJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname string) {
+func LeftJoin(leftInputCsv, rightInputCsv *InputCsv, outputCsvWriter OutputCsvWriter, leftColname, rightColname string) {
 	leftHeader, err := leftInputCsv.Read()
 	if err != nil {
 		ExitWithError(err)
@@ -129,11 +130,9 @@ func LeftJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname s
 	emptyRightRow := make([]string, numRightColumns)
 	shellRow := make([]string, numLeftColumns+numRightColumns)
 
-	outputCsv := NewOutputCsvFromInputCsvs([]*InputCsv{leftInputCsv, rightInputCsv})
-
 	// Write header.
 	concat(shellRow, leftHeader, rightCsv.header)
-	outputCsv.Write(shellRow)
+	outputCsvWriter.Write(shellRow)
 
 	// Write left-joined rows.
 	for {
@@ -149,16 +148,16 @@ func LeftJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname s
 		if len(rightRows) > 0 {
 			for _, rightRow := range rightRows {
 				concat(shellRow, row, rightRow)
-				outputCsv.Write(shellRow)
+				outputCsvWriter.Write(shellRow)
 			}
 		} else {
 			concat(shellRow, row, emptyRightRow)
-			outputCsv.Write(shellRow)
+			outputCsvWriter.Write(shellRow)
 		}
 	}
 }
 
-func RightJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname string) {
+func RightJoin(leftInputCsv, rightInputCsv *InputCsv, outputCsvWriter OutputCsvWriter, leftColname, rightColname string) {
 	rightHeader, err := rightInputCsv.Read()
 	if err != nil {
 		ExitWithError(err)
@@ -174,11 +173,9 @@ func RightJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 	emptyLeftRow := make([]string, numLeftColumns)
 	shellRow := make([]string, numLeftColumns+numRightColumns)
 
-	outputCsv := NewOutputCsvFromInputCsvs([]*InputCsv{leftInputCsv, rightInputCsv})
-
 	// Write header.
 	concat(shellRow, leftCsv.header, rightHeader)
-	outputCsv.Write(shellRow)
+	outputCsvWriter.Write(shellRow)
 
 	// Write right-joined rows.
 	for {
@@ -194,16 +191,16 @@ func RightJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 		if len(leftRows) > 0 {
 			for _, leftRow := range leftRows {
 				concat(shellRow, leftRow, row)
-				outputCsv.Write(shellRow)
+				outputCsvWriter.Write(shellRow)
 			}
 		} else {
 			concat(shellRow, emptyLeftRow, row)
-			outputCsv.Write(shellRow)
+			outputCsvWriter.Write(shellRow)
 		}
 	}
 }
 
-func OuterJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname string) {
+func OuterJoin(leftInputCsv, rightInputCsv *InputCsv, outputCsvWriter OutputCsvWriter, leftColname, rightColname string) {
 	// Basically do a left join and then append any rows from the right table
 	// that weren't already included.
 
@@ -226,11 +223,9 @@ func OuterJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 	// whether the row in the right column has been included already.
 	rightIncludeStatus := make([]bool, len(rightCsv.rows))
 
-	outputCsv := NewOutputCsvFromInputCsvs([]*InputCsv{leftInputCsv, rightInputCsv})
-
 	// Write header.
 	concat(shellRow, leftHeader, rightCsv.header)
-	outputCsv.Write(shellRow)
+	outputCsvWriter.Write(shellRow)
 
 	// Write left-joined rows.
 	for {
@@ -247,11 +242,11 @@ func OuterJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 			for _, rightRowIndex := range rightRowIndices {
 				rightIncludeStatus[rightRowIndex] = true
 				concat(shellRow, row, rightCsv.rows[rightRowIndex])
-				outputCsv.Write(shellRow)
+				outputCsvWriter.Write(shellRow)
 			}
 		} else {
 			concat(shellRow, row, emptyRightRow)
-			outputCsv.Write(shellRow)
+			outputCsvWriter.Write(shellRow)
 		}
 	}
 
@@ -261,6 +256,6 @@ func OuterJoin(leftInputCsv, rightInputCsv *InputCsv, leftColname, rightColname
 			continue
 		}
 		concat(shellRow, emptyLeftRow, row)
-		outputCsv.Write(shellRow)
+		outputCsvWriter.Write(shellRow)
 	}
 }
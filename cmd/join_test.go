@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJoinNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	tmpDir, err := ioutil.TempDir("", "gocsv-join-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	leftFilename := filepath.Join(tmpDir, "left.csv")
+	rightFilename := filepath.Join(tmpDir, "right.csv")
+	err = ioutil.WriteFile(leftFilename, []byte("1,a\n2,b\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(rightFilename, []byte("2,x\n1,y\n3,z\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first rows of both inputs are joined as data, and the joined
+	// header is not written.
+	leftInputCsv, err := NewInputCsv(leftFilename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	rightInputCsv, err := NewInputCsv(rightFilename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	var buf bytes.Buffer
+	InnerJoin(leftInputCsv, rightInputCsv, NewOutputCsvFromWriter(&buf), "1", "1")
+	expected := "1,a,1,y\n2,b,2,x\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
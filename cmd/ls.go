@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+)
+
+type LsSubcommand struct {
+	inputFiles InputFilesFlags
+}
+
+func (sub *LsSubcommand) Name() string {
+	return "ls"
+}
+func (sub *LsSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *LsSubcommand) Description() string {
+	return "List CSVs, including those in ZIP archives, with their dimensions."
+}
+func (sub *LsSubcommand) SetFlags(fs *flag.FlagSet) {
+	sub.inputFiles.SetFlags(fs)
+}
+
+func (sub *LsSubcommand) Run(args []string) {
+	filenames := sub.inputFiles.ExpandFilenamesOrPanic(args)
+	if len(filenames) == 0 {
+		ExitWithError(errors.New("No files specified"))
+	}
+	outputCsv := NewOutputCsv()
+	outputCsv.KeepHeader()
+	err := ListCsvs(filenames, outputCsv)
+	if err != nil {
+		ExitWithError(err)
+	}
+}
+
+// ListCsvs writes the filename, number of rows and number of columns of
+// each CSV, with ZIP archives expanding into the CSVs within them.
+func ListCsvs(filenames []string, outputCsvWriter OutputCsvWriter) error {
+	outputCsvWriter.Write([]string{"File", "Rows", "Columns"})
+	for _, filename := range filenames {
+		csvFilenames, err := ExpandArchive(filename)
+		if err != nil {
+			return err
+		}
+		for _, csvFilename := range csvFilenames {
+			inputCsv, err := NewInputCsv(csvFilename)
+			if err != nil {
+				return err
+			}
+			inputCsv.SetFieldsPerRecord(-1)
+			numRows, numColumns, err := CountDimensions(inputCsv)
+			inputCsv.Close()
+			if err != nil {
+				return err
+			}
+			outputCsvWriter.Write([]string{csvFilename, strconv.Itoa(numRows), strconv.Itoa(numColumns)})
+		}
+	}
+	return nil
+}
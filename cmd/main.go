@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -14,6 +15,44 @@ var (
 	VERSION string
 	// DEBUG is set by the common --debug flag
 	DEBUG bool
+	// INPUT_ENCODING is set by the common --input-encoding flag
+	INPUT_ENCODING string
+	// OUTPUT_ENCODING is set by the common --output-encoding flag
+	OUTPUT_ENCODING string
+	// INPUT_DELIMITER is set by the common --input-delimiter flag
+	INPUT_DELIMITER string
+	// INPUT_QUOTE is set by the common --input-quote flag
+	INPUT_QUOTE string
+	// INPUT_ESCAPE is set by the common --input-escape flag
+	INPUT_ESCAPE string
+	// LAZY_QUOTES is set by the common --lazy-quotes flag
+	LAZY_QUOTES bool
+	// COMMENT is set by the common --comment flag
+	COMMENT string
+	// TRIM_LEADING_SPACE is set by the common --trim-leading-space flag
+	TRIM_LEADING_SPACE bool
+	// RAGGED is set by the common --ragged flag
+	RAGGED bool
+	// OUTPUT_DELIMITER is set by the common --output-delimiter flag
+	OUTPUT_DELIMITER string
+	// CRLF is set by the common --crlf flag
+	CRLF bool
+	// QUOTE is set by the common --quote flag
+	QUOTE string
+	// OUTPUT_QUOTE is set by the common --output-quote flag
+	OUTPUT_QUOTE string
+	// OUTPUT_ESCAPE is set by the common --output-escape flag
+	OUTPUT_ESCAPE string
+	// NO_HEADER is set by the common --no-header flag
+	NO_HEADER bool
+	// SKIP_LINES is set by the common --skip-lines flag
+	SKIP_LINES int
+	// HEADER_ROW is set by the common --header-row flag
+	HEADER_ROW int
+	// AUTO_HEADER is set by the common --auto-header flag
+	AUTO_HEADER bool
+	// SKIP_FOOTER is set by the common --skip-footer flag
+	SKIP_FOOTER int
 )
 
 type Subcommand interface {
@@ -36,19 +75,26 @@ func init() {
 	RegisterSubcommand(&BeheadSubcommand{})
 	RegisterSubcommand(&CapSubcommand{})
 	RegisterSubcommand(&CleanSubcommand{})
+	RegisterSubcommand(&DedupeSubcommand{})
 	RegisterSubcommand(&DelimiterSubcommand{})
 	RegisterSubcommand(&DescribeSubcommand{})
 	RegisterSubcommand(&DimensionsSubcommand{})
+	RegisterSubcommand(&EncodingSubcommand{})
 	RegisterSubcommand(&FilterSubcommand{})
+	RegisterSubcommand(&FlattenHeaderSubcommand{})
 	RegisterSubcommand(&HeadSubcommand{})
 	RegisterSubcommand(&HeadersSubcommand{})
 	RegisterSubcommand(&JoinSubcommand{})
+	RegisterSubcommand(&LsSubcommand{})
+	RegisterSubcommand(&MergeSubcommand{})
 	RegisterSubcommand(&NcolSubcommand{})
 	RegisterSubcommand(&NrowSubcommand{})
 	RegisterSubcommand(&RenameSubcommand{})
 	RegisterSubcommand(&ReplaceSubcommand{})
 	RegisterSubcommand(&SampleSubcommand{})
 	RegisterSubcommand(&SelectSubcommand{})
+	RegisterSubcommand(&SetopSubcommand{})
+	RegisterSubcommand(&SniffSubcommand{})
 	RegisterSubcommand(&SortSubcommand{})
 	RegisterSubcommand(&SplitSubcommand{})
 	RegisterSubcommand(&SqlSubcommand{})
@@ -106,12 +152,16 @@ func Main() {
 	for _, subcommand := range subcommands {
 		if MatchesSubcommand(subcommand, subcommandName) {
 			fs := flag.NewFlagSet(subcommand.Name(), flag.ExitOnError)
-			fs.BoolVar(&DEBUG, "debug", false, "Enable debug mode")
+			setCommonFlags(fs)
 			subcommand.SetFlags(fs)
 			err := fs.Parse(args[2:])
 			if err != nil {
 				ExitWithError(err)
 			}
+			err = parseCommonFlags()
+			if err != nil {
+				ExitWithError(err)
+			}
 			subcommand.Run(fs.Args())
 			return
 		}
@@ -121,6 +171,105 @@ func Main() {
 	os.Exit(1)
 }
 
+// setCommonFlags registers the flags accepted by every subcommand. The
+// input flags are applied by InputCsv and the output flags by OutputCsv.
+func setCommonFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&DEBUG, "debug", false, "Enable debug mode")
+	fs.StringVar(&INPUT_ENCODING, "input-encoding", "", "Character encoding of the input, or auto to detect it")
+	fs.StringVar(&OUTPUT_ENCODING, "output-encoding", "", "Character encoding of the output")
+	fs.StringVar(&INPUT_DELIMITER, "input-delimiter", "", "Delimiter of the input, or auto to detect it")
+	fs.StringVar(&INPUT_DELIMITER, "delimiter", "", "Delimiter of the input, or auto to detect it (alias)")
+	fs.StringVar(&INPUT_QUOTE, "input-quote", "", "Quote character of the input, or none if fields are not quoted")
+	fs.StringVar(&INPUT_ESCAPE, "input-escape", "", "Character escaping the next character in the input, e.g. \\")
+	fs.BoolVar(&LAZY_QUOTES, "lazy-quotes", false, "Allow quotes within unquoted fields and unescaped quotes within quoted fields")
+	fs.StringVar(&COMMENT, "comment", "", "Ignore lines of the input starting with this character")
+	fs.BoolVar(&TRIM_LEADING_SPACE, "trim-leading-space", false, "Ignore leading whitespace in fields of the input")
+	fs.BoolVar(&RAGGED, "ragged", false, "Allow rows of the input to have different numbers of fields")
+	fs.StringVar(&OUTPUT_DELIMITER, "output-delimiter", "", "Delimiter of the output")
+	fs.BoolVar(&CRLF, "crlf", false, "End lines of the output with \\r\\n")
+	fs.StringVar(&QUOTE, "quote", "", "Which fields of the output to quote: minimal, all, nonnumeric or none")
+	fs.StringVar(&OUTPUT_QUOTE, "output-quote", "", "Quote character of the output")
+	fs.StringVar(&OUTPUT_ESCAPE, "output-escape", "", "Character escaping quotes in the output, and special characters with --quote none")
+	fs.BoolVar(&NO_HEADER, "no-header", false, "Treat the first row of the input as data, and do not write a header")
+	fs.IntVar(&SKIP_LINES, "skip-lines", 0, "Number of lines to skip at the start of the input")
+	fs.IntVar(&HEADER_ROW, "header-row", 0, "Row of the input with the header, skipping the rows before it")
+	fs.BoolVar(&AUTO_HEADER, "auto-header", false, "Skip the rows of the input before the first with the most common number of fields")
+	fs.IntVar(&SKIP_FOOTER, "skip-footer", 0, "Number of rows to skip at the end of the input")
+}
+
+// parseCommonFlags validates and normalizes the common flags.
+func parseCommonFlags() (err error) {
+	INPUT_ENCODING, err = ParseEncoding(INPUT_ENCODING)
+	if err != nil {
+		return
+	}
+	OUTPUT_ENCODING, err = ParseOutputEncoding(OUTPUT_ENCODING)
+	if err != nil {
+		return
+	}
+	if INPUT_DELIMITER != "" && INPUT_DELIMITER != DELIMITER_AUTO {
+		_, err = ParseMultiCharDelimiter(INPUT_DELIMITER)
+		if err != nil {
+			return
+		}
+	}
+	if INPUT_QUOTE != "" && INPUT_QUOTE != QUOTE_NONE {
+		_, err = ParseDelimiter(INPUT_QUOTE)
+		if err != nil {
+			return errors.New("Invalid quote character " + INPUT_QUOTE)
+		}
+	}
+	if INPUT_ESCAPE != "" {
+		_, err = ParseDelimiter(INPUT_ESCAPE)
+		if err != nil {
+			return errors.New("Invalid escape character " + INPUT_ESCAPE)
+		}
+	}
+	if COMMENT != "" {
+		_, err = ParseDelimiter(COMMENT)
+		if err != nil {
+			return errors.New("Invalid comment character " + COMMENT)
+		}
+	}
+	if OUTPUT_DELIMITER != "" {
+		_, err = ParseMultiCharDelimiter(OUTPUT_DELIMITER)
+		if err != nil {
+			return
+		}
+	}
+	if QUOTE != "" {
+		_, err = ParseQuoteStyle(QUOTE)
+		if err != nil {
+			return
+		}
+	}
+	if OUTPUT_QUOTE != "" {
+		_, err = ParseDelimiter(OUTPUT_QUOTE)
+		if err != nil {
+			return errors.New("Invalid quote character " + OUTPUT_QUOTE)
+		}
+	}
+	if OUTPUT_ESCAPE != "" {
+		_, err = ParseDelimiter(OUTPUT_ESCAPE)
+		if err != nil {
+			return errors.New("Invalid escape character " + OUTPUT_ESCAPE)
+		}
+	}
+	if SKIP_LINES < 0 {
+		return errors.New("Invalid argument --skip-lines")
+	}
+	if HEADER_ROW < 0 {
+		return errors.New("Invalid argument --header-row")
+	}
+	if SKIP_FOOTER < 0 {
+		return errors.New("Invalid argument --skip-footer")
+	}
+	if HEADER_ROW > 0 && AUTO_HEADER {
+		return errors.New("Cannot specify both --header-row and --auto-header")
+	}
+	return
+}
+
 func MatchesSubcommand(sub Subcommand, name string) bool {
 	if name == sub.Name() {
 		return true
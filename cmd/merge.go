@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+type MergeSubcommand struct {
+	columnsString string
+	reverse       bool
+	noInference   bool
+	inputFiles    InputFilesFlags
+}
+
+func (sub *MergeSubcommand) Name() string {
+	return "merge"
+}
+func (sub *MergeSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *MergeSubcommand) Description() string {
+	return "Merge multiple sorted CSVs into one sorted CSV."
+}
+func (sub *MergeSubcommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&sub.columnsString, "columns", "", "Columns the inputs are sorted by")
+	fs.StringVar(&sub.columnsString, "c", "", "Columns the inputs are sorted by (shorthand)")
+	fs.BoolVar(&sub.reverse, "reverse", false, "Inputs are sorted in reverse")
+	fs.BoolVar(&sub.noInference, "no-inference", false, "Skip inference of input")
+	sub.inputFiles.SetFlags(fs)
+}
+
+func (sub *MergeSubcommand) Run(args []string) {
+	inputCsvs := GetInputCsvsOrPanic(sub.inputFiles.ExpandFilenamesOrPanic(args), -1)
+	outputCsv := NewOutputCsvFromInputCsvs(inputCsvs)
+	sub.RunMerge(inputCsvs, outputCsv)
+}
+
+func (sub *MergeSubcommand) RunMerge(inputCsvs []*InputCsv, outputCsvWriter OutputCsvWriter) {
+	if sub.columnsString == "" {
+		fmt.Fprintln(os.Stderr, "Missing required argument --columns")
+		os.Exit(1)
+	}
+	columns := GetArrayFromCsvString(sub.columnsString)
+
+	headers := make([][]string, len(inputCsvs))
+	for i, inputCsv := range inputCsvs {
+		header, err := inputCsv.Read()
+		if err != nil {
+			ExitWithError(err)
+		}
+		headers[i] = header
+	}
+	err := CheckHeadersMatch(headers)
+	if err != nil {
+		ExitWithError(err)
+	}
+	header := headers[0]
+
+	sortColumns := ParseSortColumnsOrPanic(header, columns, sub.reverse)
+
+	outputCsvWriter.Write(header)
+
+	readers := make([]RowReader, len(inputCsvs))
+	for i, inputCsv := range inputCsvs {
+		readers[i] = inputCsv
+	}
+	if sub.noInference {
+		err = MergeSortedRows(readers, sortColumns, outputCsvWriter)
+	} else {
+		err = MergeSortedRowsInferringTypes(readers, sortColumns, outputCsvWriter)
+	}
+	if err != nil {
+		ExitWithError(err)
+	}
+}
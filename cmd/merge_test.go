@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataFoxCo/gocsv/csv"
+)
+
+func TestRunMerge(t *testing.T) {
+	testCases := []struct {
+		columns     string
+		noInference bool
+		rows        [][]string
+	}{
+		{"Number", false, [][]string{
+			[]string{"Number", "String"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"3", "Three"},
+			[]string{"3", "Another Three"},
+			[]string{"10", "Ten"},
+			[]string{"20", "Twenty"},
+		}},
+		{"Number,String", false, [][]string{
+			[]string{"Number", "String"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"3", "Another Three"},
+			[]string{"3", "Three"},
+			[]string{"10", "Ten"},
+			[]string{"20", "Twenty"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			inputCsvs, err := GetInputCsvs([]string{"../test-files/merge-1.csv", "../test-files/merge-2.csv"}, -1)
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(MergeSubcommand)
+			sub.columnsString = tt.columns
+			sub.noInference = tt.noInference
+			sub.RunMerge(inputCsvs, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMergeSortedRowsUnsorted(t *testing.T) {
+	readers := []RowReader{
+		csv.NewReader(strings.NewReader("a\nc\n")),
+		csv.NewReader(strings.NewReader("b\na\n")),
+	}
+	sortColumns := []SortColumn{{index: 0, columnType: STRING_TYPE}}
+	toc := new(testOutputCsv)
+	err := MergeSortedRows(readers, sortColumns, toc)
+	if err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
+
+func TestMergeSortedRowsInferringTypes(t *testing.T) {
+	// The readers can only be read once, and are sorted as numbers.
+	readers := []RowReader{
+		csv.NewReader(strings.NewReader("2\n10\n")),
+		csv.NewReader(strings.NewReader("\n9\n100\n")),
+	}
+	sortColumns := []SortColumn{{index: 0, columnType: STRING_TYPE}}
+	toc := new(testOutputCsv)
+	err := MergeSortedRowsInferringTypes(readers, sortColumns, toc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := [][]string{{""}, {"2"}, {"9"}, {"10"}, {"100"}}
+	err = assertRowsEqual(expected, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+	if sortColumns[0].columnType != INT_TYPE {
+		t.Errorf("Expected the column to be inferred as integers")
+	}
+}
+
+func TestMergeSortedRowsInferringWiderTypes(t *testing.T) {
+	// The first input is sorted as strings because of its last row, which
+	// only shows once all of it has been read.
+	readers := []RowReader{
+		csv.NewReader(strings.NewReader("10\n9\nabc\n")),
+		csv.NewReader(strings.NewReader("1\n")),
+	}
+	sortColumns := []SortColumn{{index: 0, columnType: STRING_TYPE}}
+	toc := new(testOutputCsv)
+	err := MergeSortedRowsInferringTypes(readers, sortColumns, toc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := [][]string{{"1"}, {"10"}, {"9"}, {"abc"}}
+	err = assertRowsEqual(expected, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+	if sortColumns[0].columnType != STRING_TYPE {
+		t.Errorf("Expected the column to be inferred as strings")
+	}
+}
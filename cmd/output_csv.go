@@ -1,9 +1,19 @@
 package cmd
 
 import (
-	"encoding/csv"
-	"fmt"
+	"errors"
+	"io"
 	"os"
+	"unicode/utf8"
+
+	"github.com/DataFoxCo/gocsv/csv"
+)
+
+const (
+	QUOTE_MINIMAL    = "minimal"
+	QUOTE_ALL        = "all"
+	QUOTE_NONNUMERIC = "nonnumeric"
+	QUOTE_NONE       = "none"
 )
 
 type OutputCsvWriter interface {
@@ -12,7 +22,9 @@ type OutputCsvWriter interface {
 
 type OutputCsv struct {
 	writeBom         bool
+	skipHeader       bool
 	hasWrittenHeader bool
+	w                io.Writer
 	writer           *csv.Writer
 }
 
@@ -29,7 +41,11 @@ func NewFileOutputCsvFromInputCsv(inputCsv *InputCsv, file *os.File) (oc *Output
 }
 
 func NewOutputCsvFromInputCsvsAndFile(inputCsvs []*InputCsv, file *os.File) (oc *OutputCsv) {
-	oc = NewOutputCsvFromFile(file)
+	return NewOutputCsvFromInputCsvsAndWriter(inputCsvs, file)
+}
+
+func NewOutputCsvFromInputCsvsAndWriter(inputCsvs []*InputCsv, w io.Writer) (oc *OutputCsv) {
+	oc = NewOutputCsvFromWriter(w)
 	// If _any_ of the input CSVs has a BOM, then conserve the BOM.
 	for _, inputCsv := range inputCsvs {
 		if inputCsv.hasBom {
@@ -45,23 +61,93 @@ func NewOutputCsv() (oc *OutputCsv) {
 }
 
 func NewOutputCsvFromFile(file *os.File) (oc *OutputCsv) {
+	return NewOutputCsvFromWriter(file)
+}
+
+// NewOutputCsvFromWriter writes a CSV as specified by the common output
+// flags. Output in UTF-16 always starts with a byte order mark, since it
+// is hard to recognize otherwise. With --no-header, the first row written
+// is taken to be the header and is left out.
+func NewOutputCsvFromWriter(w io.Writer) (oc *OutputCsv) {
 	oc = new(OutputCsv)
-	oc.writer = csv.NewWriter(file)
+	oc.w = NewEncodingWriter(w, OUTPUT_ENCODING)
+	oc.writer = csv.NewWriter(oc.w)
+	oc.writeBom = IsUtf16Encoding(OUTPUT_ENCODING)
+	if OUTPUT_DELIMITER != "" {
+		// The delimiter has already been validated by parseCommonFlags.
+		delimiter, _ := ParseMultiCharDelimiter(OUTPUT_DELIMITER)
+		oc.SetDelimiterString(delimiter)
+	}
+	oc.writer.UseCRLF = CRLF
+	if QUOTE != "" {
+		// The quoting style has already been validated by parseCommonFlags.
+		oc.writer.Quoting, _ = ParseQuoteStyle(QUOTE)
+	}
+	if OUTPUT_QUOTE != "" {
+		oc.writer.Quote, _ = ParseDelimiter(OUTPUT_QUOTE)
+	}
+	if OUTPUT_ESCAPE != "" {
+		oc.writer.Escape, _ = ParseDelimiter(OUTPUT_ESCAPE)
+	}
+	oc.skipHeader = NO_HEADER
 	return
 }
 
+// ParseQuoteStyle returns the csv.QuoteStyle for the name given to --quote.
+func ParseQuoteStyle(quote string) (csv.QuoteStyle, error) {
+	switch quote {
+	case QUOTE_MINIMAL:
+		return csv.QuoteMinimal, nil
+	case QUOTE_ALL:
+		return csv.QuoteAll, nil
+	case QUOTE_NONNUMERIC:
+		return csv.QuoteNonNumeric, nil
+	case QUOTE_NONE:
+		return csv.QuoteNone, nil
+	}
+	return csv.QuoteMinimal, errors.New("Invalid quoting style " + quote)
+}
+
 func (oc *OutputCsv) SetDelimiter(delimiter rune) {
 	oc.writer.Comma = delimiter
+	oc.writer.Delimiter = ""
+}
+
+// SetDelimiterString sets a delimiter that may be more than one
+// character long.
+func (oc *OutputCsv) SetDelimiterString(delimiter string) {
+	if utf8.RuneCountInString(delimiter) == 1 {
+		r, _ := utf8.DecodeRuneInString(delimiter)
+		oc.SetDelimiter(r)
+	} else {
+		oc.writer.Delimiter = delimiter
+	}
+}
+
+// KeepHeader makes the output write its first row even with --no-header.
+// This is for output that is not made of rows of the input, such as a
+// report about the input or a converted spreadsheet, since --no-header
+// then only applies to how the input is read.
+func (oc *OutputCsv) KeepHeader() {
+	oc.skipHeader = false
 }
 
 func (oc *OutputCsv) Write(row []string) error {
+	if oc.skipHeader {
+		// Any BOM is written with the first row of data instead.
+		oc.skipHeader = false
+		return nil
+	}
 	if !oc.hasWrittenHeader {
 		oc.hasWrittenHeader = true
+		// The BOM is written on its own rather than as part of the
+		// first field, so that it is never enclosed in quotes. Every
+		// row is flushed once written, so none is buffered before it.
 		if oc.writeBom {
-			rowCopy := make([]string, len(row))
-			copy(rowCopy, row)
-			rowCopy[0] = fmt.Sprintf("%s%s", BOM_STRING, row[0])
-			return oc.writeRow(rowCopy)
+			_, err := io.WriteString(oc.w, BOM_STRING)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return oc.writeRow(row)
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestOutputCsvCommonFlags(t *testing.T) {
+	OUTPUT_DELIMITER, CRLF = ";", true
+	defer func() {
+		OUTPUT_DELIMITER, CRLF = "", false
+	}()
+	var buf bytes.Buffer
+	oc := NewOutputCsvFromWriter(&buf)
+	oc.Write([]string{"Name", "Amount"})
+	oc.Write([]string{"Coffee", "2,50"})
+	oc.Write([]string{"Tea; green", "1,80"})
+	expected := "Name;Amount\r\nCoffee;2,50\r\n\"Tea; green\";1,80\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestOutputCsvQuote(t *testing.T) {
+	testCases := []struct {
+		quote       string
+		outputQuote string
+		escape      string
+		expected    string
+	}{
+		{"", "", "", "Name,Amount\nTea,1.80\n\"a,b\",-2\n"},
+		{QUOTE_MINIMAL, "", "", "Name,Amount\nTea,1.80\n\"a,b\",-2\n"},
+		{QUOTE_ALL, "", "", "\"Name\",\"Amount\"\n\"Tea\",\"1.80\"\n\"a,b\",\"-2\"\n"},
+		{QUOTE_NONNUMERIC, "", "", "\"Name\",\"Amount\"\n\"Tea\",1.80\n\"a,b\",-2\n"},
+		{QUOTE_NONE, "", "\\", "Name,Amount\nTea,1.80\na\\,b,-2\n"},
+		{QUOTE_ALL, "'", "", "'Name','Amount'\n'Tea','1.80'\n'a,b','-2'\n"},
+		{QUOTE_MINIMAL, "", "\\", "Name,Amount\nTea,1.80\n\"a,b\",-2\n"},
+	}
+	defer func() {
+		QUOTE, OUTPUT_QUOTE, OUTPUT_ESCAPE = "", "", ""
+	}()
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			QUOTE, OUTPUT_QUOTE, OUTPUT_ESCAPE = tt.quote, tt.outputQuote, tt.escape
+			var buf bytes.Buffer
+			oc := NewOutputCsvFromWriter(&buf)
+			oc.Write([]string{"Name", "Amount"})
+			oc.Write([]string{"Tea", "1.80"})
+			oc.Write([]string{"a,b", "-2"})
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestParseQuoteStyle(t *testing.T) {
+	if _, err := ParseQuoteStyle("some"); err == nil {
+		t.Error("Expected an error for an invalid quoting style")
+	}
+}
+
+func TestOutputCsvBomQuote(t *testing.T) {
+	testCases := []struct {
+		quote    string
+		expected string
+	}{
+		{QUOTE_MINIMAL, BOM_STRING + "Name,Amount\n\" a\",1\n"},
+		{QUOTE_ALL, BOM_STRING + "\"Name\",\"Amount\"\n\" a\",\"1\"\n"},
+		{QUOTE_NONNUMERIC, BOM_STRING + "\"Name\",\"Amount\"\n\" a\",1\n"},
+		{QUOTE_NONE, BOM_STRING + "Name,Amount\n a,1\n"},
+	}
+	defer func() { QUOTE = "" }()
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			QUOTE = tt.quote
+			var buf bytes.Buffer
+			oc := NewOutputCsvFromWriter(&buf)
+			oc.writeBom = true
+			oc.Write([]string{"Name", "Amount"})
+			oc.Write([]string{" a", "1"})
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, buf.String())
+			}
+		})
+	}
+
+	// The BOM is encoded like the rows, and only written once.
+	OUTPUT_ENCODING = ENCODING_UTF16LE
+	defer func() { OUTPUT_ENCODING = "" }()
+	var buf bytes.Buffer
+	oc := NewOutputCsvFromWriter(&buf)
+	oc.Write([]string{"a"})
+	oc.Write([]string{"b"})
+	expected := "\xff\xfea\x00\n\x00b\x00\n\x00"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestOutputCsvNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	var buf bytes.Buffer
+	oc := NewOutputCsvFromWriter(&buf)
+	oc.writeBom = true
+	oc.Write([]string{"1", "2"})
+	oc.Write([]string{"a", "b"})
+	oc.Write([]string{"c", "d"})
+	expected := BOM_STRING + "a,b\nc,d\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestOutputCsvKeepHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	var buf bytes.Buffer
+	oc := NewOutputCsvFromWriter(&buf)
+	oc.KeepHeader()
+	err := ListCsvs([]string{"../test-files/simple-sort.csv"}, oc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	// The header of the report is written, and the first row of the
+	// input is counted as a row.
+	expected := "File,Rows,Columns\n../test-files/simple-sort.csv,5,2\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
@@ -30,6 +30,9 @@ func (sub *RenameSubcommand) SetFlags(fs *flag.FlagSet) {
 func (sub *RenameSubcommand) Run(args []string) {
 	inputCsvs := GetInputCsvsOrPanic(args, 1)
 	outputCsv := NewOutputCsvFromInputCsv(inputCsvs[0])
+	// The renamed header is written even with --no-header, naming the
+	// columns of an input without a header.
+	outputCsv.KeepHeader()
 	sub.RunRename(inputCsvs[0], outputCsv)
 }
 
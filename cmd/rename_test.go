@@ -37,3 +37,27 @@ func TestRunRename(t *testing.T) {
 		})
 	}
 }
+
+func TestRunRenameNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	toc := new(testOutputCsv)
+	sub := &RenameSubcommand{columnsString: "2", namesString: "Name"}
+	sub.RunRename(ic, toc)
+	err = assertRowsEqual([][]string{
+		{"1", "Name"},
+		{"Number", "String"},
+		{"1", "One"},
+		{"2", "Two"},
+		{"-1", "Minus One"},
+		{"2", "Another Two"},
+	}, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -1,15 +1,21 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
-	"fmt"
-	"os"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
 )
 
 type SampleSubcommand struct {
-	replace bool
-	numRows int
-	seed    int
+	replace        bool
+	numRows        int
+	fraction       float64
+	stratifyString string
+	seed           int
 }
 
 func (sub *SampleSubcommand) Name() string {
@@ -24,37 +30,285 @@ func (sub *SampleSubcommand) Description() string {
 func (sub *SampleSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&sub.replace, "replace", false, "Sample with replacement")
 	fs.IntVar(&sub.numRows, "n", 0, "Number of rows to sample")
+	fs.Float64Var(&sub.fraction, "fraction", 0, "Fraction of rows to sample")
+	fs.StringVar(&sub.stratifyString, "stratify", "", "Columns whose values define the groups to sample from")
 	fs.IntVar(&sub.seed, "seed", 0, "Seed for random number generation")
 }
 
 func (sub *SampleSubcommand) Run(args []string) {
-	if sub.numRows < 1 {
-		fmt.Fprintln(os.Stderr, "Invalid required argument -n")
-		os.Exit(1)
+	inputCsvs := GetInputCsvsOrPanic(args, 1)
+	outputCsv := NewOutputCsvFromInputCsv(inputCsvs[0])
+	sub.RunSample(inputCsvs[0], outputCsv)
+}
+
+func (sub *SampleSubcommand) RunSample(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter) {
+	if sub.numRows == 0 && sub.fraction == 0 {
+		ExitWithError(errors.New("Missing required argument -n or --fraction"))
+	}
+	if sub.numRows != 0 && sub.fraction != 0 {
+		ExitWithError(errors.New("Cannot specify both -n and --fraction"))
+	}
+	if sub.numRows < 0 {
+		ExitWithError(errors.New("Invalid argument -n"))
+	}
+	if sub.fraction < 0 || sub.fraction > 1 {
+		ExitWithError(errors.New("Invalid argument --fraction"))
+	}
+	if sub.fraction != 0 && sub.replace {
+		ExitWithError(errors.New("Cannot sample a fraction of rows with replacement"))
 	}
 
-	inputCsvs := GetInputCsvsOrPanic(args, 1)
-	Sample(inputCsvs[0], sub.numRows, sub.replace, sub.seed)
+	var stratifyColumns []string
+	if sub.stratifyString == "" {
+		stratifyColumns = make([]string, 0)
+	} else {
+		stratifyColumns = GetArrayFromCsvString(sub.stratifyString)
+	}
+
+	rng := NewSampleRand(sub.seed)
+
+	if len(stratifyColumns) > 0 {
+		if sub.fraction != 0 {
+			SampleFractionStratified(inputCsv, outputCsvWriter, stratifyColumns, sub.fraction, rng)
+		} else {
+			SampleStratified(inputCsv, outputCsvWriter, stratifyColumns, sub.numRows, sub.replace, rng)
+		}
+	} else {
+		if sub.fraction != 0 {
+			SampleFraction(inputCsv, outputCsvWriter, sub.fraction, rng)
+		} else {
+			Sample(inputCsv, outputCsvWriter, sub.numRows, sub.replace, rng)
+		}
+	}
+}
+
+// NewSampleRand creates the random number generator used for sampling. If
+// seed is 0, the generator is seeded from the current time.
+func NewSampleRand(seed int) *rand.Rand {
+	if seed == 0 {
+		return rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
+	}
+	return rand.New(rand.NewSource(int64(seed)))
 }
 
-func Sample(inputCsv *InputCsv, numRows int, replace bool, seed int) {
+// Sample writes numRows rows chosen uniformly at random, in the order they
+// appear in the input. Rows are sampled with a reservoir, so only the
+// sampled rows are held in memory.
+func Sample(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, numRows int, replace bool, rng *rand.Rand) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
 
-	imc := NewInMemoryCsvFromInputCsv(inputCsv)
+	reservoir := NewRowReservoir(numRows, replace, rng)
+	forEachSampleRow(inputCsv, func(index int, row []string) {
+		reservoir.Add(index, row)
+	})
 
-	if numRows > imc.NumRows() && !replace {
-		fmt.Fprintln(os.Stderr, "Cannot sample more rows than exist")
-		os.Exit(1)
+	if reservoir.NumSeen() < numRows && !replace {
+		ExitWithError(errors.New("Cannot sample more rows than exist"))
 	}
 
-	rowIndices := imc.SampleRowIndices(numRows, replace, seed)
+	// Write header.
+	outputCsvWriter.Write(header)
+
+	writeSampledRows(outputCsvWriter, reservoir.Rows())
+}
 
-	outputCsv := NewOutputCsvFromInputCsv(inputCsv)
+// SampleFraction writes each row with probability fraction, streaming the
+// rows from the input.
+func SampleFraction(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, fraction float64, rng *rand.Rand) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
 
 	// Write header.
-	outputCsv.Write(imc.header)
+	outputCsvWriter.Write(header)
+
+	forEachSampleRow(inputCsv, func(index int, row []string) {
+		if rng.Float64() < fraction {
+			outputCsvWriter.Write(row)
+		}
+	})
+}
+
+// SampleStratified writes numRows rows chosen uniformly at random from each
+// group of rows sharing the same values in the stratify columns. Groups with
+// fewer rows are written in full when sampling without replacement.
+func SampleStratified(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, stratifyColumns []string, numRows int, replace bool, rng *rand.Rand) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, stratifyColumns)
 
-	for _, rowIndex := range rowIndices {
-		outputCsv.Write(imc.rows[rowIndex])
+	reservoirs := make(map[string]*RowReservoir)
+	forEachSampleRow(inputCsv, func(index int, row []string) {
+		key := GetRowKey(row, columnIndices)
+		reservoir, ok := reservoirs[key]
+		if !ok {
+			reservoir = NewRowReservoir(numRows, replace, rng)
+			reservoirs[key] = reservoir
+		}
+		reservoir.Add(index, row)
+	})
+
+	sampledRows := make([]SampledRow, 0)
+	for _, reservoir := range reservoirs {
+		sampledRows = append(sampledRows, reservoir.Rows()...)
 	}
 
+	// Write header.
+	outputCsvWriter.Write(header)
+
+	writeSampledRows(outputCsvWriter, sampledRows)
+}
+
+// SampleFractionStratified writes a fraction of the rows of each group of
+// rows sharing the same values in the stratify columns, so that each group
+// is represented in proportion to its size. The number of rows sampled from
+// a group is rounded to the nearest integer. Since the group sizes are not
+// known until the end of the input, all rows are held in memory.
+func SampleFractionStratified(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, stratifyColumns []string, fraction float64, rng *rand.Rand) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, stratifyColumns)
+
+	groupKeys := make([]string, 0)
+	groups := make(map[string][]SampledRow)
+	forEachSampleRow(inputCsv, func(index int, row []string) {
+		key := GetRowKey(row, columnIndices)
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+		}
+		groups[key] = append(groups[key], SampledRow{index: index, row: row})
+	})
+
+	sampledRows := make([]SampledRow, 0)
+	for _, key := range groupKeys {
+		group := groups[key]
+		numGroupRows := int(math.Round(fraction * float64(len(group))))
+		// Partial Fisher-Yates shuffle to choose the sampled rows.
+		for i := 0; i < numGroupRows; i++ {
+			j := i + rng.Intn(len(group)-i)
+			group[i], group[j] = group[j], group[i]
+		}
+		sampledRows = append(sampledRows, group[:numGroupRows]...)
+	}
+
+	// Write header.
+	outputCsvWriter.Write(header)
+
+	writeSampledRows(outputCsvWriter, sampledRows)
+}
+
+// SampledRow is a sampled row along with its index in the input.
+type SampledRow struct {
+	index int
+	row   []string
+}
+
+// RowReservoir samples a fixed number of rows uniformly at random from a
+// stream of rows of unknown length.
+//
+// Without replacement, this is Algorithm R: the first rows fill the
+// reservoir, and then the i-th row replaces a random row of the reservoir
+// with probability size/i. With replacement, each slot of the reservoir
+// is an independent reservoir of a single row, so the i-th row replaces
+// each slot with probability 1/i. Rather than drawing a random number for
+// every slot, the gaps between the replaced slots are drawn from a
+// geometric distribution, so adding a row takes constant time on average.
+type RowReservoir struct {
+	size    int
+	replace bool
+	rng     *rand.Rand
+	numSeen int
+	rows    []SampledRow
+}
+
+func NewRowReservoir(size int, replace bool, rng *rand.Rand) *RowReservoir {
+	return &RowReservoir{
+		size:    size,
+		replace: replace,
+		rng:     rng,
+		rows:    make([]SampledRow, 0, size),
+	}
+}
+
+// Add offers a row to the reservoir.
+func (r *RowReservoir) Add(index int, row []string) {
+	r.numSeen++
+	sampledRow := SampledRow{index: index, row: row}
+	if r.replace {
+		if r.numSeen == 1 {
+			for i := 0; i < r.size; i++ {
+				r.rows = append(r.rows, sampledRow)
+			}
+			return
+		}
+		logKeep := math.Log1p(-1 / float64(r.numSeen))
+		for i := r.skipSlots(logKeep); i < r.size; i += 1 + r.skipSlots(logKeep) {
+			r.rows[i] = sampledRow
+		}
+	} else if len(r.rows) < r.size {
+		r.rows = append(r.rows, sampledRow)
+	} else {
+		j := r.rng.Intn(r.numSeen)
+		if j < r.size {
+			r.rows[j] = sampledRow
+		}
+	}
+}
+
+// skipSlots returns the number of slots to leave before the next one to
+// replace, when each slot is kept with probability exp(logKeep).
+func (r *RowReservoir) skipSlots(logKeep float64) int {
+	// 1 - Float64() is in (0, 1], so its logarithm is finite.
+	skip := math.Floor(math.Log(1-r.rng.Float64()) / logKeep)
+	if skip >= float64(r.size) {
+		return r.size
+	}
+	return int(skip)
+}
+
+// NumSeen returns the number of rows offered to the reservoir.
+func (r *RowReservoir) NumSeen() int {
+	return r.numSeen
+}
+
+// Rows returns the sampled rows.
+func (r *RowReservoir) Rows() []SampledRow {
+	return r.rows
+}
+
+func forEachSampleRow(inputCsv *InputCsv, f func(index int, row []string)) {
+	index := 0
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		f(index, row)
+		index++
+	}
+}
+
+// writeSampledRows writes the sampled rows in the order they appeared
+// in the input.
+func writeSampledRows(outputCsvWriter OutputCsvWriter, sampledRows []SampledRow) {
+	sort.SliceStable(sampledRows, func(i, j int) bool {
+		return sampledRows[i].index < sampledRows[j].index
+	})
+	for _, sampledRow := range sampledRows {
+		outputCsvWriter.Write(sampledRow.row)
+	}
 }
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestRunSample(t *testing.T) {
+	testCases := []struct {
+		numRows        int
+		fraction       float64
+		replace        bool
+		stratifyString string
+		numSampled     int
+		labelCounts    map[string]int
+	}{
+		{5, 0, false, "", 5, nil},
+		{20, 0, false, "", 20, map[string]int{"a": 12, "b": 6, "c": 2}},
+		{30, 0, true, "", 30, nil},
+		{0, 1, false, "", 20, map[string]int{"a": 12, "b": 6, "c": 2}},
+		{2, 0, false, "Label", 6, map[string]int{"a": 2, "b": 2, "c": 2}},
+		{3, 0, false, "Label", 8, map[string]int{"a": 3, "b": 3, "c": 2}},
+		{3, 0, true, "Label", 9, map[string]int{"a": 3, "b": 3, "c": 3}},
+		{0, 0.5, false, "Label", 10, map[string]int{"a": 6, "b": 3, "c": 1}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			runSample := func() [][]string {
+				ic, err := NewInputCsv("../test-files/sample.csv")
+				if err != nil {
+					t.Fatal("Unexpected error", err)
+				}
+				toc := new(testOutputCsv)
+				sub := new(SampleSubcommand)
+				sub.numRows = tt.numRows
+				sub.fraction = tt.fraction
+				sub.replace = tt.replace
+				sub.stratifyString = tt.stratifyString
+				sub.seed = 42
+				sub.RunSample(ic, toc)
+				return toc.rows
+			}
+			rows := runSample()
+			if len(rows) != tt.numSampled+1 {
+				t.Fatalf("Expected %d sampled rows but got %d", tt.numSampled, len(rows)-1)
+			}
+			err := assertRowsEqual([][]string{{"ID", "Label"}}, rows[:1])
+			if err != nil {
+				t.Error(err)
+			}
+
+			// Rows are written in the order of the input.
+			labelCounts := make(map[string]int)
+			prevId := 0
+			for _, row := range rows[1:] {
+				id, err := strconv.Atoi(row[0])
+				if err != nil {
+					t.Fatal("Unexpected error", err)
+				}
+				if id < prevId || (id == prevId && !tt.replace) {
+					t.Errorf("Expected rows in input order but got %d after %d", id, prevId)
+				}
+				prevId = id
+				labelCounts[row[1]]++
+			}
+			if tt.labelCounts != nil {
+				for label, count := range tt.labelCounts {
+					if labelCounts[label] != count {
+						t.Errorf("Expected %d rows with label %s but got %d", count, label, labelCounts[label])
+					}
+				}
+			}
+
+			// The same seed gives the same sample.
+			err = assertRowsEqual(rows, runSample())
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRowReservoirUniform(t *testing.T) {
+	numTrials := 10000
+	counts := make([]int, 10)
+	rng := NewSampleRand(1)
+	for trial := 0; trial < numTrials; trial++ {
+		reservoir := NewRowReservoir(3, false, rng)
+		for i := range counts {
+			reservoir.Add(i, []string{strconv.Itoa(i)})
+		}
+		for _, sampledRow := range reservoir.Rows() {
+			counts[sampledRow.index]++
+		}
+	}
+	// Each row should be sampled in about 3/10 of the trials.
+	for i, count := range counts {
+		if count < 2700 || count > 3300 {
+			t.Errorf("Row %d was sampled %d times out of %d", i, count, numTrials)
+		}
+	}
+}
+
+func TestRowReservoirWithReplacementUniform(t *testing.T) {
+	numTrials := 2000
+	counts := make([]int, 10)
+	rng := NewSampleRand(1)
+	for trial := 0; trial < numTrials; trial++ {
+		reservoir := NewRowReservoir(5, true, rng)
+		for i := range counts {
+			reservoir.Add(i, []string{strconv.Itoa(i)})
+		}
+		for _, sampledRow := range reservoir.Rows() {
+			counts[sampledRow.index]++
+		}
+	}
+	// Each row should fill about 1/10 of the 5 slots of each trial.
+	for i, count := range counts {
+		if count < 900 || count > 1100 {
+			t.Errorf("Row %d was sampled %d times out of %d", i, count, 5*numTrials)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 type SelectSubcommand struct {
 	columnsString string
 	exclude       bool
+	follow        bool
 }
 
 func (sub *SelectSubcommand) Name() string {
@@ -25,6 +26,7 @@ func (sub *SelectSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.StringVar(&sub.columnsString, "columns", "", "Columns to select")
 	fs.StringVar(&sub.columnsString, "c", "", "Columns to select (shorthand)")
 	fs.BoolVar(&sub.exclude, "exclude", false, "Whether to exclude the specified columns")
+	fs.BoolVar(&sub.follow, "follow", false, "Keep selecting from rows as they are appended to the file")
 }
 
 func (sub *SelectSubcommand) Run(args []string) {
@@ -40,6 +42,13 @@ func (sub *SelectSubcommand) RunSelect(inputCsv *InputCsv, outputCsvWriter Outpu
 	}
 	columns := GetArrayFromCsvString(sub.columnsString)
 
+	if sub.follow {
+		err := inputCsv.Follow(FOLLOW_POLL_INTERVAL)
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
+
 	if sub.exclude {
 		ExcludeColumns(inputCsv, outputCsvWriter, columns)
 	} else {
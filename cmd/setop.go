@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	SETOP_UNION     = "union"
+	SETOP_INTERSECT = "intersect"
+	SETOP_EXCEPT    = "except"
+)
+
+type SetopSubcommand struct {
+	operation     string
+	columnsString string
+	sources       bool
+	inputFiles    InputFilesFlags
+}
+
+func (sub *SetopSubcommand) Name() string {
+	return "setop"
+}
+func (sub *SetopSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *SetopSubcommand) Description() string {
+	return "Compute the union, intersection or difference of rows across CSVs."
+}
+func (sub *SetopSubcommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&sub.operation, "operation", SETOP_UNION, "Set operation: union, intersect or except")
+	fs.StringVar(&sub.operation, "op", SETOP_UNION, "Set operation: union, intersect or except (shorthand)")
+	fs.StringVar(&sub.columnsString, "columns", "", "Columns to use for comparison")
+	fs.StringVar(&sub.columnsString, "c", "", "Columns to use for comparison (shorthand)")
+	fs.BoolVar(&sub.sources, "sources", false, "Whether to append a Sources column")
+	sub.inputFiles.SetFlags(fs)
+}
+
+func (sub *SetopSubcommand) Run(args []string) {
+	inputCsvs := GetInputCsvsOrPanic(sub.inputFiles.ExpandFilenamesOrPanic(args), -1)
+	outputCsv := NewOutputCsvFromInputCsvs(inputCsvs)
+	sub.RunSetop(inputCsvs, outputCsv)
+}
+
+func (sub *SetopSubcommand) RunSetop(inputCsvs []*InputCsv, outputCsvWriter OutputCsvWriter) {
+	var columns []string
+	if sub.columnsString == "" {
+		columns = make([]string, 0)
+	} else {
+		columns = GetArrayFromCsvString(sub.columnsString)
+	}
+
+	if sub.operation != SETOP_UNION && sub.operation != SETOP_INTERSECT && sub.operation != SETOP_EXCEPT {
+		ExitWithError(fmt.Errorf("Invalid operation \"%s\"", sub.operation))
+	}
+	if sub.operation != SETOP_UNION && len(inputCsvs) < 2 {
+		ExitWithError(errors.New("Must provide at least two CSVs"))
+	}
+
+	SetOperation(inputCsvs, outputCsvWriter, sub.operation, columns, sub.sources)
+}
+
+// setopEntry is a distinct row along with the inputs in which it appears.
+type setopEntry struct {
+	row       []string
+	inSources []bool
+}
+
+// SetOperation computes a set operation on the rows of the input CSVs,
+// where two rows are considered equal if they match on the specified
+// columns. The first occurrence of each distinct row is written, in input
+// order.
+//
+// For a union, every distinct row is written. For an intersection, rows from
+// the first CSV that appear in every other CSV are written. For a difference,
+// rows from the first CSV that appear in none of the other CSVs are written.
+// Only the distinct rows of the first CSV are held in memory for
+// intersections and differences.
+//
+// If includeSources is true, a "Sources" column listing the filenames of the
+// CSVs in which the row appears is appended.
+func SetOperation(inputCsvs []*InputCsv, outputCsvWriter OutputCsvWriter, operation string, columns []string, includeSources bool) {
+	headers := make([][]string, len(inputCsvs))
+	for i, inputCsv := range inputCsvs {
+		header, err := inputCsv.Read()
+		if err != nil {
+			ExitWithError(err)
+		}
+		headers[i] = header
+	}
+	err := CheckHeadersMatch(headers)
+	if err != nil {
+		ExitWithError(err)
+	}
+	header := headers[0]
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	entries := make([]*setopEntry, 0)
+	entriesByKey := make(map[string]*setopEntry)
+	for i, inputCsv := range inputCsvs {
+		for {
+			row, err := inputCsv.Read()
+			if err != nil {
+				if err == io.EOF {
+					break
+				} else {
+					ExitWithError(err)
+				}
+			}
+			key := GetRowKey(row, columnIndices)
+			entry, ok := entriesByKey[key]
+			if !ok {
+				if i > 0 && operation != SETOP_UNION {
+					continue
+				}
+				entry = &setopEntry{row: row, inSources: make([]bool, len(inputCsvs))}
+				entriesByKey[key] = entry
+				entries = append(entries, entry)
+			}
+			entry.inSources[i] = true
+		}
+	}
+
+	if includeSources {
+		header = append(header, "Sources")
+	}
+	outputCsvWriter.Write(header)
+
+	for _, entry := range entries {
+		if !setopEntryMatches(entry, operation) {
+			continue
+		}
+		row := entry.row
+		if includeSources {
+			sources := make([]string, 0)
+			for i, inSource := range entry.inSources {
+				if inSource {
+					sources = append(sources, inputCsvs[i].Filename())
+				}
+			}
+			row = append(row, strings.Join(sources, ";"))
+		}
+		outputCsvWriter.Write(row)
+	}
+}
+
+func setopEntryMatches(entry *setopEntry, operation string) bool {
+	if operation == SETOP_UNION {
+		return true
+	}
+	for i, inSource := range entry.inSources {
+		if i == 0 {
+			continue
+		}
+		if operation == SETOP_INTERSECT && !inSource {
+			return false
+		}
+		if operation == SETOP_EXCEPT && inSource {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRowKey returns a string that uniquely identifies the values of
+// a row in the specified columns, suitable for use as a map key.
+func GetRowKey(row []string, columnIndices []int) string {
+	var sb strings.Builder
+	for _, columnIndex := range columnIndices {
+		cell := row[columnIndex]
+		sb.WriteString(strconv.Itoa(len(cell)))
+		sb.WriteByte(':')
+		sb.WriteString(cell)
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRunSetop(t *testing.T) {
+	testCases := []struct {
+		operation     string
+		columnsString string
+		sources       bool
+		rows          [][]string
+	}{
+		{"union", "", false, [][]string{
+			[]string{"ID", "Name"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"3", "Three"},
+			[]string{"3", "Tres"},
+			[]string{"4", "Four"},
+		}},
+		{"union", "ID", false, [][]string{
+			[]string{"ID", "Name"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"3", "Three"},
+			[]string{"4", "Four"},
+		}},
+		{"intersect", "", false, [][]string{
+			[]string{"ID", "Name"},
+			[]string{"2", "Two"},
+		}},
+		{"intersect", "ID", false, [][]string{
+			[]string{"ID", "Name"},
+			[]string{"2", "Two"},
+			[]string{"3", "Three"},
+		}},
+		{"except", "", false, [][]string{
+			[]string{"ID", "Name"},
+			[]string{"1", "One"},
+			[]string{"3", "Three"},
+		}},
+		{"except", "ID", false, [][]string{
+			[]string{"ID", "Name"},
+			[]string{"1", "One"},
+		}},
+		{"union", "ID", true, [][]string{
+			[]string{"ID", "Name", "Sources"},
+			[]string{"1", "One", "../test-files/setop-1.csv"},
+			[]string{"2", "Two", "../test-files/setop-1.csv;../test-files/setop-2.csv"},
+			[]string{"3", "Three", "../test-files/setop-1.csv;../test-files/setop-2.csv"},
+			[]string{"4", "Four", "../test-files/setop-2.csv"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			inputCsvs, err := GetInputCsvs([]string{"../test-files/setop-1.csv", "../test-files/setop-2.csv"}, -1)
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(SetopSubcommand)
+			sub.operation = tt.operation
+			sub.columnsString = tt.columnsString
+			sub.sources = tt.sources
+			sub.RunSetop(inputCsvs, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
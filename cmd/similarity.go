@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeForComparison lowercases a string, trims it and collapses
+// runs of whitespace into a single space.
+func NormalizeForComparison(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Tokenize splits a string into lowercase tokens of letters and digits.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// LevenshteinDistance computes the minimum number of single rune insertions,
+// deletions and substitutions needed to change one string into the other.
+func LevenshteinDistance(a, b string) int {
+	ar := []rune(a)
+	br := []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+// LevenshteinSimilarity normalizes the Levenshtein distance of the
+// normalized strings into a similarity between 0 and 1.
+func LevenshteinSimilarity(a, b string) float64 {
+	a = NormalizeForComparison(a)
+	b = NormalizeForComparison(b)
+	maxLen := len([]rune(a))
+	if bLen := len([]rune(b)); bLen > maxLen {
+		maxLen = bLen
+	}
+	if maxLen == 0 {
+		return 1.0
+	}
+	return 1.0 - float64(LevenshteinDistance(a, b))/float64(maxLen)
+}
+
+// JaccardSimilarity is the size of the intersection divided by the size
+// of the union of two sets of tokens.
+func JaccardSimilarity(aTokens, bTokens []string) float64 {
+	aSet := make(map[string]bool)
+	for _, token := range aTokens {
+		aSet[token] = true
+	}
+	bSet := make(map[string]bool)
+	for _, token := range bTokens {
+		bSet[token] = true
+	}
+	if len(aSet) == 0 && len(bSet) == 0 {
+		return 1.0
+	}
+	numIntersection := 0
+	for token := range aSet {
+		if bSet[token] {
+			numIntersection++
+		}
+	}
+	numUnion := len(aSet) + len(bSet) - numIntersection
+	return float64(numIntersection) / float64(numUnion)
+}
+
+// TokenJaccardSimilarity is the Jaccard similarity of the tokens of two strings.
+func TokenJaccardSimilarity(a, b string) float64 {
+	return JaccardSimilarity(Tokenize(a), Tokenize(b))
+}
+
+// PhoneticSimilarity is the Jaccard similarity of the phonetic
+// codes of the tokens of two strings.
+func PhoneticSimilarity(a, b string, encode func(string) string) float64 {
+	return JaccardSimilarity(encodeTokens(a, encode), encodeTokens(b, encode))
+}
+
+func encodeTokens(s string, encode func(string) string) []string {
+	codes := make([]string, 0)
+	for _, token := range Tokenize(s) {
+		code := encode(token)
+		if code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// asciiLetters returns the ASCII letters of a string in upper case.
+func asciiLetters(s string) []byte {
+	letters := make([]byte, 0, len(s))
+	for _, r := range strings.ToUpper(s) {
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, byte(r))
+		}
+	}
+	return letters
+}
+
+func soundexDigit(c byte) byte {
+	switch c {
+	case 'B', 'F', 'P', 'V':
+		return '1'
+	case 'C', 'G', 'J', 'K', 'Q', 'S', 'X', 'Z':
+		return '2'
+	case 'D', 'T':
+		return '3'
+	case 'L':
+		return '4'
+	case 'M', 'N':
+		return '5'
+	case 'R':
+		return '6'
+	}
+	return '0'
+}
+
+// Soundex computes the American Soundex code of a word, e.g. "R163" for
+// both "Robert" and "Rupert". Non-letters are ignored.
+func Soundex(s string) string {
+	letters := asciiLetters(s)
+	if len(letters) == 0 {
+		return ""
+	}
+	code := []byte{letters[0]}
+	last := soundexDigit(letters[0])
+	for _, c := range letters[1:] {
+		if len(code) == 4 {
+			break
+		}
+		// H and W do not separate letters with the same code.
+		if c == 'H' || c == 'W' {
+			continue
+		}
+		digit := soundexDigit(c)
+		if digit != '0' && digit != last {
+			code = append(code, digit)
+		}
+		last = digit
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+func isMetaphoneVowel(c byte) bool {
+	return c == 'A' || c == 'E' || c == 'I' || c == 'O' || c == 'U'
+}
+
+// Metaphone computes the original Metaphone code of a word, in which
+// similar sounding words such as "Smith" and "Smyth" share a code.
+// Non-letters are ignored.
+func Metaphone(s string) string {
+	w := asciiLetters(s)
+	if len(w) == 0 {
+		return ""
+	}
+
+	// Handle the initial letters.
+	if len(w) > 1 {
+		switch string(w[:2]) {
+		case "AE", "GN", "KN", "PN", "WR":
+			w = w[1:]
+		case "WH":
+			w = append([]byte{'W'}, w[2:]...)
+		}
+	}
+	if w[0] == 'X' {
+		w[0] = 'S'
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+	isFrontVowel := func(c byte) bool {
+		return c == 'E' || c == 'I' || c == 'Y'
+	}
+
+	code := make([]byte, 0, len(w))
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		// Skip duplicate adjacent letters, except for C.
+		if c != 'C' && i > 0 && w[i-1] == c {
+			continue
+		}
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				code = append(code, c)
+			}
+		case 'B':
+			if !(i == len(w)-1 && at(i-1) == 'M') {
+				code = append(code, 'B')
+			}
+		case 'C':
+			if at(i+1) == 'I' && at(i+2) == 'A' {
+				code = append(code, 'X')
+			} else if at(i+1) == 'H' {
+				if at(i-1) == 'S' {
+					code = append(code, 'K')
+				} else {
+					code = append(code, 'X')
+				}
+				i++
+			} else if isFrontVowel(at(i + 1)) {
+				if at(i-1) != 'S' {
+					code = append(code, 'S')
+				}
+			} else {
+				code = append(code, 'K')
+			}
+		case 'D':
+			if at(i+1) == 'G' && isFrontVowel(at(i+2)) {
+				code = append(code, 'J')
+				i++
+			} else {
+				code = append(code, 'T')
+			}
+		case 'G':
+			if at(i+1) == 'H' && !(i+2 >= len(w) || isMetaphoneVowel(at(i+2))) {
+				// Silent as in "night".
+			} else if at(i+1) == 'N' && (i+2 == len(w) || (string(w[i+1:]) == "NED")) {
+				// Silent as in "sign" or "signed".
+			} else if isFrontVowel(at(i+1)) && at(i-1) != 'G' {
+				code = append(code, 'J')
+			} else {
+				code = append(code, 'K')
+			}
+		case 'H':
+			prev := at(i - 1)
+			afterVowel := i > 0 && isMetaphoneVowel(prev)
+			afterModifier := prev == 'C' || prev == 'S' || prev == 'P' || prev == 'T' || prev == 'G'
+			if !afterModifier && !(afterVowel && !isMetaphoneVowel(at(i+1))) {
+				code = append(code, 'H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				code = append(code, 'K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				code = append(code, 'F')
+			} else {
+				code = append(code, 'P')
+			}
+		case 'Q':
+			code = append(code, 'K')
+		case 'S':
+			if at(i+1) == 'H' {
+				code = append(code, 'X')
+				i++
+			} else if at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A') {
+				code = append(code, 'X')
+			} else {
+				code = append(code, 'S')
+			}
+		case 'T':
+			if at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A') {
+				code = append(code, 'X')
+			} else if at(i+1) == 'H' {
+				code = append(code, '0')
+				i++
+			} else if !(at(i+1) == 'C' && at(i+2) == 'H') {
+				code = append(code, 'T')
+			}
+		case 'V':
+			code = append(code, 'F')
+		case 'W', 'Y':
+			if isMetaphoneVowel(at(i + 1)) {
+				code = append(code, c)
+			}
+		case 'X':
+			code = append(code, 'K', 'S')
+		case 'Z':
+			code = append(code, 'S')
+		default:
+			// F, J, L, M, N and R are unchanged.
+			code = append(code, c)
+		}
+	}
+	return string(code)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DataFoxCo/gocsv/csv"
+)
+
+// SNIFF_SAMPLE_SIZE is the number of bytes at the start of an input that
+// are inspected to detect its format.
+const SNIFF_SAMPLE_SIZE = 64 * 1024
+
+// DELIMITER_AUTO as the delimiter detects the delimiter of the input.
+const DELIMITER_AUTO = "auto"
+
+// SNIFF_DELIMITERS are the delimiters that can be detected, in order of
+// preference when more than one is equally likely.
+var SNIFF_DELIMITERS = []rune{',', '\t', ';', '|'}
+
+// SNIFF_QUOTES are the quote characters that can be detected.
+var SNIFF_QUOTES = []rune{'"', '\''}
+
+type SniffSubcommand struct {
+	asJson bool
+}
+
+func (sub *SniffSubcommand) Name() string {
+	return "sniff"
+}
+func (sub *SniffSubcommand) Aliases() []string {
+	return []string{}
+}
+func (sub *SniffSubcommand) Description() string {
+	return "Detect the delimiter, quoting and header of a CSV."
+}
+func (sub *SniffSubcommand) SetFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&sub.asJson, "json", false, "Output results as JSON")
+}
+
+func (sub *SniffSubcommand) Run(args []string) {
+	inputCsvs := GetInputCsvsOrPanic(args, 1)
+	dialect, err := SniffInputCsv(inputCsvs[0])
+	if err != nil {
+		ExitWithError(err)
+	}
+	if sub.asJson {
+		encoded, err := json.MarshalIndent(dialect, "", "  ")
+		if err != nil {
+			ExitWithError(err)
+		}
+		fmt.Println(string(encoded))
+	} else {
+		outputCsv := NewOutputCsv()
+		outputCsv.KeepHeader()
+		outputCsv.Write([]string{"Delimiter", "Quote", "LineTerminator", "BOM", "Header"})
+		outputCsv.Write([]string{
+			escapeSpecialCharacters(string(dialect.Delimiter)),
+			string(dialect.Quote),
+			escapeSpecialCharacters(dialect.LineTerminator),
+			strconv.FormatBool(dialect.HasBom),
+			strconv.FormatBool(dialect.HasHeader),
+		})
+	}
+}
+
+// Dialect describes the format of a CSV.
+type Dialect struct {
+	Delimiter      rune
+	Quote          rune
+	LineTerminator string
+	HasBom         bool
+	HasHeader      bool
+}
+
+func (d Dialect) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Delimiter      string `json:"delimiter"`
+		Quote          string `json:"quote"`
+		LineTerminator string `json:"lineTerminator"`
+		HasBom         bool   `json:"bom"`
+		HasHeader      bool   `json:"header"`
+	}{string(d.Delimiter), string(d.Quote), d.LineTerminator, d.HasBom, d.HasHeader})
+}
+
+func escapeSpecialCharacters(s string) string {
+	return strings.NewReplacer("\t", "\\t", "\r", "\\r", "\n", "\\n").Replace(s)
+}
+
+// SniffInputCsv detects the format of an input from its first bytes,
+// without consuming them.
+func SniffInputCsv(inputCsv *InputCsv) (Dialect, error) {
+	return SniffInputCsvAfterLines(inputCsv, 0)
+}
+
+// SniffInputCsvAfterLines is like SniffInputCsv, but leaves the first
+// numLines lines of the input out of the sample.
+func SniffInputCsvAfterLines(inputCsv *InputCsv, numLines int) (Dialect, error) {
+	sample, err := inputCsv.bufReader.Peek(inputCsv.bufReader.Size())
+	if err != nil && err != io.EOF {
+		return Dialect{}, err
+	}
+	truncated := err == nil
+	for i := 0; i < numLines && len(sample) > 0; i++ {
+		end := bytes.IndexByte(sample, '\n')
+		if end == -1 {
+			sample = sample[len(sample):]
+		} else {
+			sample = sample[end+1:]
+		}
+	}
+	dialect := Sniff(sample, truncated)
+	dialect.HasBom = inputCsv.hasBom
+	return dialect, nil
+}
+
+// Sniff detects the format of a CSV from a sample of its start. If
+// truncated is true, the last line of the sample is assumed to be
+// incomplete and is ignored.
+func Sniff(sample []byte, truncated bool) Dialect {
+	if truncated {
+		if i := bytes.LastIndexAny(sample, "\r\n"); i != -1 {
+			sample = sample[:i+1]
+		}
+	}
+	dialect := Dialect{Quote: sniffQuote(sample)}
+	dialect.Delimiter = sniffDelimiter(sample, dialect.Quote)
+	dialect.LineTerminator = sniffLineTerminator(sample, dialect.Quote)
+	dialect.HasHeader = sniffHeader(sample, dialect)
+	return dialect
+}
+
+// sniffQuote picks the quote character that most often starts a field,
+// i.e. appears at the start of a line or after a possible delimiter.
+// Double quotes are preferred when neither is used.
+func sniffQuote(sample []byte) rune {
+	best := SNIFF_QUOTES[0]
+	bestCount := 0
+	for _, quote := range SNIFF_QUOTES {
+		count := 0
+		atFieldStart := true
+		for _, r := range string(sample) {
+			if r == quote && atFieldStart {
+				count++
+			}
+			atFieldStart = r == '\n' || r == '\r' || isSniffDelimiter(r)
+		}
+		if count > bestCount {
+			best = quote
+			bestCount = count
+		}
+	}
+	return best
+}
+
+func isSniffDelimiter(r rune) bool {
+	for _, delimiter := range SNIFF_DELIMITERS {
+		if r == delimiter {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffLines splits a sample into lines, ignoring line breaks within
+// quoted fields.
+func sniffLines(sample []byte, quote rune) []string {
+	lines := make([]string, 0)
+	var line strings.Builder
+	inQuotes := false
+	text := string(sample)
+	for i, r := range text {
+		if r == quote {
+			inQuotes = !inQuotes
+		}
+		if !inQuotes && (r == '\n' || r == '\r') {
+			if r == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+				continue
+			}
+			lines = append(lines, line.String())
+			line.Reset()
+			continue
+		}
+		line.WriteRune(r)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// sniffDelimiter picks the delimiter that occurs, outside of quotes, the
+// same number of times on the most lines.
+func sniffDelimiter(sample []byte, quote rune) rune {
+	lines := sniffLines(sample, quote)
+	best := SNIFF_DELIMITERS[0]
+	bestScore := 0
+	for _, delimiter := range SNIFF_DELIMITERS {
+		frequencies := make(map[int]int)
+		for _, line := range lines {
+			count := 0
+			inQuotes := false
+			for _, r := range line {
+				if r == quote {
+					inQuotes = !inQuotes
+				} else if r == delimiter && !inQuotes {
+					count++
+				}
+			}
+			if count > 0 {
+				frequencies[count]++
+			}
+		}
+		score := 0
+		for _, numLines := range frequencies {
+			if numLines > score {
+				score = numLines
+			}
+		}
+		if score > bestScore {
+			best = delimiter
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// sniffLineTerminator finds the first line break outside of quotes.
+func sniffLineTerminator(sample []byte, quote rune) string {
+	inQuotes := false
+	text := string(sample)
+	for i, r := range text {
+		if r == quote {
+			inQuotes = !inQuotes
+		} else if !inQuotes && r == '\n' {
+			return "\n"
+		} else if !inQuotes && r == '\r' {
+			if i+1 < len(text) && text[i+1] == '\n' {
+				return "\r\n"
+			}
+			return "\r"
+		}
+	}
+	return "\n"
+}
+
+// sniffHeader guesses whether the first row is a header by comparing each
+// of its cells with the rest of its column. A cell that is not a number
+// above a column of numbers, or whose length differs from a column of
+// values of the same length, suggests a header, while a cell like the
+// rest of its column or an empty cell suggests otherwise. If the rows
+// give no indication either way, the first row is assumed to be a header.
+func sniffHeader(sample []byte, dialect Dialect) bool {
+	// The csv package only understands line feeds.
+	text := strings.Replace(string(sample), "\r\n", "\n", -1)
+	text = strings.Replace(text, "\r", "\n", -1)
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = dialect.Delimiter
+	reader.Quote = dialect.Quote
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) < 2 {
+		return true
+	}
+	header := rows[0]
+	votes := 0
+	for i, name := range header {
+		if name == "" {
+			votes--
+			continue
+		}
+		allNumbers := true
+		length := -1
+		sameLength := true
+		numValues := 0
+		for _, row := range rows[1:] {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			numValues++
+			if _, err := strconv.ParseFloat(row[i], 64); err != nil {
+				allNumbers = false
+			}
+			valueLength := utf8.RuneCountInString(row[i])
+			if length == -1 {
+				length = valueLength
+			} else if valueLength != length {
+				sameLength = false
+			}
+		}
+		if numValues == 0 {
+			continue
+		}
+		if allNumbers {
+			if _, err := strconv.ParseFloat(name, 64); err != nil {
+				votes++
+			} else {
+				votes--
+			}
+		} else if sameLength {
+			if utf8.RuneCountInString(name) != length {
+				votes++
+			} else {
+				votes--
+			}
+		}
+	}
+	return votes >= 0
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	testCases := []struct {
+		sample    string
+		truncated bool
+		dialect   Dialect
+	}{
+		{"Name,Age\nAlice,30\nBob,41\n", false, Dialect{',', '"', "\n", false, true}},
+		{"Name\tAge\r\nAlice\t30\r\nBob\t41\r\n", false, Dialect{'\t', '"', "\r\n", false, true}},
+		{"Name;City\n\"Smith, Jane\";\"Paris; France\"\n\"Doe, John\";Rome\n", false, Dialect{';', '"', "\n", false, true}},
+		{"'A'|'B'\r'1,5'|'2'\r'3,5'|'4'\r", false, Dialect{'|', '\'', "\r", false, true}},
+		{"1,2,3\n4,5,6\n7,8,9\n", false, Dialect{',', '"', "\n", false, false}},
+		{"abc;de\nfgh;ij\nklm;no\n", false, Dialect{';', '"', "\n", false, false}},
+		{"ID;Code\n1;AB\n2;CD\n3;E", true, Dialect{';', '"', "\n", false, true}},
+		{"", false, Dialect{',', '"', "\n", false, true}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			dialect := Sniff([]byte(tt.sample), tt.truncated)
+			if dialect != tt.dialect {
+				t.Errorf("Expected %+v but got %+v", tt.dialect, dialect)
+			}
+		})
+	}
+}
+
+func TestInputCsvAutoDelimiter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-sniff-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "semicolons.csv")
+	err = ioutil.WriteFile(filename, []byte("\xEF\xBB\xBFName;Amount\nCoffee;2,50\nTea;1,80\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	INPUT_DELIMITER = DELIMITER_AUTO
+	defer func() { INPUT_DELIMITER = "" }()
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	dialect, err := SniffInputCsv(ic)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if dialect.Delimiter != ';' || !dialect.HasBom || !dialect.HasHeader {
+		t.Errorf("Unexpected dialect %+v", dialect)
+	}
+	rows, err := ic.ReadAll()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err = assertRowsEqual([][]string{
+		{"Name", "Amount"},
+		{"Coffee", "2,50"},
+		{"Tea", "1,80"},
+	}, rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInputCsvAutoDelimiterAfterPreamble(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-sniff-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "preamble.csv")
+	contents := "Report, January, 2021\nPrepared by: Finance, Accounts, Audit\nSource: bank, card, cash\nName;Amount\nCoffee;2,50\nTea;1,80\n"
+	err = ioutil.WriteFile(filename, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		skipLines int
+		headerRow int
+	}{
+		{3, 0},
+		{0, 4},
+		{1, 3},
+	}
+	INPUT_DELIMITER = DELIMITER_AUTO
+	defer func() { INPUT_DELIMITER = "" }()
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			SKIP_LINES, HEADER_ROW = tt.skipLines, tt.headerRow
+			defer func() {
+				SKIP_LINES, HEADER_ROW = 0, 0
+			}()
+			ic, err := NewInputCsv(filename)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			defer ic.Close()
+			rows, err := ic.ReadAll()
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			err = assertRowsEqual([][]string{
+				{"Name", "Amount"},
+				{"Coffee", "2,50"},
+				{"Tea", "1,80"},
+			}, rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
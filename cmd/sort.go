@@ -3,6 +3,7 @@ package cmd
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -10,6 +11,7 @@ type SortSubcommand struct {
 	columnsString string
 	reverse       bool
 	noInference   bool
+	maxMemory     string
 }
 
 func (sub *SortSubcommand) Name() string {
@@ -22,10 +24,11 @@ func (sub *SortSubcommand) Description() string {
 	return "Sort a CSV based on one or more columns."
 }
 func (sub *SortSubcommand) SetFlags(fs *flag.FlagSet) {
-	fs.StringVar(&sub.columnsString, "columns", "", "Columns to select")
-	fs.StringVar(&sub.columnsString, "c", "", "Columns to select (shorthand)")
+	fs.StringVar(&sub.columnsString, "columns", "", "Columns to sort by")
+	fs.StringVar(&sub.columnsString, "c", "", "Columns to sort by (shorthand)")
 	fs.BoolVar(&sub.reverse, "reverse", false, "Sort in reverse")
 	fs.BoolVar(&sub.noInference, "no-inference", false, "Skip inference of input")
+	fs.StringVar(&sub.maxMemory, "max-memory", "", "Maximum memory to use before sorting via temporary files (e.g. 512MB)")
 }
 
 func (sub *SortSubcommand) Run(args []string) {
@@ -41,23 +44,43 @@ func (sub *SortSubcommand) SortCsv(inputCsv *InputCsv, outputCsvWriter OutputCsv
 	}
 	columns := GetArrayFromCsvString(sub.columnsString)
 
-	imc := NewInMemoryCsvFromInputCsv(inputCsv)
-	columnIndices := GetIndicesForColumnsOrPanic(imc.header, columns)
-	columnTypes := make([]ColumnType, len(columnIndices))
-	for i, columnIndex := range columnIndices {
-		if sub.noInference {
-			columnTypes[i] = STRING_TYPE
-		} else {
-			columnTypes[i] = imc.InferType(columnIndex)
+	maxMemory := int64(DEFAULT_SORT_MAX_MEMORY)
+	if sub.maxMemory != "" {
+		var err error
+		maxMemory, err = ParseByteSize(sub.maxMemory)
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+	sortColumns := ParseSortColumnsOrPanic(header, columns, sub.reverse)
+
+	sorter := NewExternalSorter(sortColumns, sub.noInference, maxMemory)
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		err = sorter.Add(row)
+		if err != nil {
+			ExitWithError(err)
 		}
 	}
-	imc.SortRows(columnIndices, columnTypes, sub.reverse)
 
 	// Write header.
-	outputCsvWriter.Write(imc.header)
+	outputCsvWriter.Write(header)
 
 	// Write sorted rows.
-	for _, row := range imc.rows {
-		outputCsvWriter.Write(row)
+	err = sorter.WriteSorted(outputCsvWriter)
+	if err != nil {
+		ExitWithError(err)
 	}
 }
@@ -0,0 +1,266 @@
+package cmd
+
+import "strings"
+
+// SortColumn describes how a single column participates in a sort.
+type SortColumn struct {
+	index           int
+	columnType      ColumnType
+	descending      bool
+	nullsLast       bool
+	natural         bool
+	caseInsensitive bool
+}
+
+// sortColumnSpec is a column specification along with the sort options
+// parsed from it, before it has been resolved against a header.
+type sortColumnSpec struct {
+	column          string
+	descending      bool
+	nullPlacement   string
+	natural         bool
+	caseInsensitive bool
+}
+
+// ParseSortColumns resolves column specifications of the form
+// "COLUMN[:OPTION[:OPTION...]]" against a header. The supported options are
+// "asc", "desc", "nulls-first", "nulls-last", "natural" and "nocase".
+// Options that are not recognized are treated as part of the column name so
+// that headers containing colons can still be referenced.
+//
+// By default empty cells are treated as smaller than any other value, so they
+// come first in ascending columns and last in descending columns. If reverse
+// is true, the direction of every column is flipped.
+func ParseSortColumns(header []string, columns []string, reverse bool) (sortColumns []SortColumn, err error) {
+	for _, column := range columns {
+		spec := parseSortColumnSpec(column)
+		indices, err := GetIndicesForColumn(header, spec.column)
+		if err != nil {
+			return nil, err
+		}
+		descending := spec.descending != reverse
+		nullsLast := descending
+		if spec.nullPlacement == "nulls-first" {
+			nullsLast = false
+		} else if spec.nullPlacement == "nulls-last" {
+			nullsLast = true
+		}
+		for _, index := range indices {
+			sortColumns = append(sortColumns, SortColumn{
+				index:           index,
+				columnType:      STRING_TYPE,
+				descending:      descending,
+				nullsLast:       nullsLast,
+				natural:         spec.natural,
+				caseInsensitive: spec.caseInsensitive,
+			})
+		}
+	}
+	return
+}
+
+// ParseSortColumnsOrPanic is a simple wrapper around ParseSortColumns
+// that will simply panic if ParseSortColumns returns an error.
+func ParseSortColumnsOrPanic(header []string, columns []string, reverse bool) []SortColumn {
+	sortColumns, err := ParseSortColumns(header, columns, reverse)
+	if err != nil {
+		ExitWithError(err)
+	}
+	return sortColumns
+}
+
+func parseSortColumnSpec(column string) (spec sortColumnSpec) {
+	parts := strings.Split(column, ":")
+	numOptions := 0
+	for i := len(parts) - 1; i > 0; i-- {
+		option := strings.ToLower(parts[i])
+		switch option {
+		case "asc":
+			spec.descending = false
+		case "desc":
+			spec.descending = true
+		case "nulls-first", "nulls-last":
+			spec.nullPlacement = option
+		case "natural":
+			spec.natural = true
+		case "nocase", "case-insensitive":
+			spec.caseInsensitive = true
+		default:
+			spec.column = strings.Join(parts[:i+1], ":")
+			return
+		}
+		numOptions++
+	}
+	spec.column = strings.Join(parts[:len(parts)-numOptions], ":")
+	return
+}
+
+// InferSortColumnTypes sets the type of each sort column to the type
+// inferred from the rows.
+func InferSortColumnTypes(sortColumns []SortColumn, rows [][]string) {
+	for i := range sortColumns {
+		sortColumns[i].columnType = NULL_TYPE
+		for _, row := range rows {
+			sortColumns[i].UpdateType(row[sortColumns[i].index])
+			if sortColumns[i].columnType == STRING_TYPE {
+				break
+			}
+		}
+	}
+}
+
+// UpdateType widens the type of the sort column to accommodate elem.
+func (sc *SortColumn) UpdateType(elem string) {
+	if sc.columnType == STRING_TYPE {
+		return
+	}
+	thisType := InferTypeWithHint(elem, sc.columnType)
+	if thisType > sc.columnType {
+		sc.columnType = thisType
+	}
+}
+
+// CompareRows compares two rows on the sort columns, interpreting each
+// column according to its type and options. It returns a negative number
+// if row1 sorts before row2, a positive number if row1 sorts after row2,
+// and 0 if the rows are equal on all of the columns.
+func CompareRows(row1, row2 []string, sortColumns []SortColumn) int {
+	for _, sc := range sortColumns {
+		elem1 := row1[sc.index]
+		elem2 := row2[sc.index]
+		isElem1Null := IsNullType(elem1)
+		isElem2Null := IsNullType(elem2)
+		if isElem1Null && isElem2Null {
+			continue
+		}
+		// Null placement does not depend on the direction of the column.
+		if isElem1Null || isElem2Null {
+			if isElem1Null == sc.nullsLast {
+				return 1
+			}
+			return -1
+		}
+		cmp := compareElems(elem1, elem2, sc)
+		if cmp != 0 {
+			if sc.descending {
+				return -cmp
+			}
+			return cmp
+		}
+	}
+	return 0
+}
+
+func compareElems(elem1, elem2 string, sc SortColumn) int {
+	columnType := sc.columnType
+	if columnType == FLOAT_TYPE {
+		elem1Val := ParseFloat64OrPanic(elem1)
+		elem2Val := ParseFloat64OrPanic(elem2)
+		if elem1Val < elem2Val {
+			return -1
+		} else if elem1Val > elem2Val {
+			return 1
+		}
+	} else if columnType == INT_TYPE {
+		elem1Val := ParseInt64OrPanic(elem1)
+		elem2Val := ParseInt64OrPanic(elem2)
+		if elem1Val < elem2Val {
+			return -1
+		} else if elem1Val > elem2Val {
+			return 1
+		}
+	} else if columnType == DATETIME_TYPE {
+		elem1Val := ParseDatetimeOrPanic(elem1)
+		elem2Val := ParseDatetimeOrPanic(elem2)
+		if elem1Val.Before(elem2Val) {
+			return -1
+		} else if elem1Val.After(elem2Val) {
+			return 1
+		}
+	} else if columnType == DATE_TYPE {
+		_, elem1Val := ParseDateOrPanic(elem1)
+		_, elem2Val := ParseDateOrPanic(elem2)
+		if elem1Val.Before(elem2Val) {
+			return -1
+		} else if elem1Val.After(elem2Val) {
+			return 1
+		}
+	} else {
+		if sc.caseInsensitive {
+			elem1 = strings.ToLower(elem1)
+			elem2 = strings.ToLower(elem2)
+		}
+		if sc.natural {
+			return CompareNatural(elem1, elem2)
+		}
+		return strings.Compare(elem1, elem2)
+	}
+	return 0
+}
+
+// CompareNatural compares two strings such that runs of digits are
+// compared by their numeric value, so "file2" sorts before "file10".
+func CompareNatural(a, b string) int {
+	ar := []rune(a)
+	br := []rune(b)
+	i, j := 0, 0
+	for i < len(ar) && j < len(br) {
+		if isDigit(ar[i]) && isDigit(br[j]) {
+			iEnd := i
+			for iEnd < len(ar) && isDigit(ar[iEnd]) {
+				iEnd++
+			}
+			jEnd := j
+			for jEnd < len(br) && isDigit(br[jEnd]) {
+				jEnd++
+			}
+			cmp := compareDigits(ar[i:iEnd], br[j:jEnd])
+			if cmp != 0 {
+				return cmp
+			}
+			i, j = iEnd, jEnd
+			continue
+		}
+		if ar[i] < br[j] {
+			return -1
+		} else if ar[i] > br[j] {
+			return 1
+		}
+		i++
+		j++
+	}
+	if len(ar)-i < len(br)-j {
+		return -1
+	} else if len(ar)-i > len(br)-j {
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareDigits compares two runs of digits by numeric value, ignoring
+// leading zeros.
+func compareDigits(a, b []rune) int {
+	for len(a) > 1 && a[0] == '0' {
+		a = a[1:]
+	}
+	for len(b) > 1 && b[0] == '0' {
+		b = b[1:]
+	}
+	if len(a) < len(b) {
+		return -1
+	} else if len(a) > len(b) {
+		return 1
+	}
+	for k := range a {
+		if a[k] < b[k] {
+			return -1
+		} else if a[k] > b[k] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 )
@@ -16,13 +17,13 @@ func TestSortCsv(t *testing.T) {
 			[]string{"Number", "String"},
 			[]string{"-1", "Minus One"},
 			[]string{"1", "One"},
-			[]string{"2", "Another Two"},
 			[]string{"2", "Two"},
+			[]string{"2", "Another Two"},
 		}},
 		{"Number", true, false, [][]string{
 			[]string{"Number", "String"},
-			[]string{"2", "Another Two"},
 			[]string{"2", "Two"},
+			[]string{"2", "Another Two"},
 			[]string{"1", "One"},
 			[]string{"-1", "Minus One"},
 		}},
@@ -30,13 +31,13 @@ func TestSortCsv(t *testing.T) {
 			[]string{"Number", "String"},
 			[]string{"-1", "Minus One"},
 			[]string{"1", "One"},
-			[]string{"2", "Another Two"},
 			[]string{"2", "Two"},
+			[]string{"2", "Another Two"},
 		}},
 		{"Number", true, true, [][]string{
 			[]string{"Number", "String"},
-			[]string{"2", "Another Two"},
 			[]string{"2", "Two"},
+			[]string{"2", "Another Two"},
 			[]string{"1", "One"},
 			[]string{"-1", "Minus One"},
 		}},
@@ -77,3 +78,251 @@ func TestSortCsv(t *testing.T) {
 		})
 	}
 }
+
+func TestSortCsvWithSpilling(t *testing.T) {
+	testCases := []struct {
+		columns   string
+		reverse   bool
+		maxMemory string
+		rows      [][]string
+	}{
+		{"Number", false, "1", [][]string{
+			[]string{"Number", "String"},
+			[]string{"-1", "Minus One"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"2", "Another Two"},
+		}},
+		{"Number", true, "1", [][]string{
+			[]string{"Number", "String"},
+			[]string{"2", "Two"},
+			[]string{"2", "Another Two"},
+			[]string{"1", "One"},
+			[]string{"-1", "Minus One"},
+		}},
+		{"String", false, "100", [][]string{
+			[]string{"Number", "String"},
+			[]string{"2", "Another Two"},
+			[]string{"-1", "Minus One"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsv("../test-files/simple-sort.csv")
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(SortSubcommand)
+			sub.columnsString = tt.columns
+			sub.reverse = tt.reverse
+			sub.maxMemory = tt.maxMemory
+			sub.SortCsv(ic, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestExternalSorterResortsRuns(t *testing.T) {
+	// The first run is sorted as integers, but the final inferred
+	// type of the column is a string.
+	rows := [][]string{
+		[]string{"10"},
+		[]string{"9"},
+		[]string{"abc"},
+		[]string{"1"},
+	}
+	sorter := NewExternalSorter([]SortColumn{{index: 0}}, false, 80)
+	for _, row := range rows {
+		err := sorter.Add(row)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+	}
+	toc := new(testOutputCsv)
+	err := sorter.WriteSorted(toc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := [][]string{
+		[]string{"1"},
+		[]string{"10"},
+		[]string{"9"},
+		[]string{"abc"},
+	}
+	err = assertRowsEqual(expected, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExternalSorterMergesInPasses(t *testing.T) {
+	sorter := NewExternalSorter([]SortColumn{{index: 0}}, true, 1)
+	sorter.maxOpenRuns = 2
+	for _, value := range []string{"e", "b", "d", "a", "c", "b"} {
+		err := sorter.Add([]string{value, fmt.Sprintf("%d", len(sorter.runs))})
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+	}
+	toc := new(testOutputCsv)
+	err := sorter.WriteSorted(toc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := [][]string{
+		[]string{"a", "3"},
+		[]string{"b", "1"},
+		[]string{"b", "5"},
+		[]string{"c", "4"},
+		[]string{"d", "2"},
+		[]string{"e", "0"},
+	}
+	err = assertRowsEqual(expected, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExternalSorterPreservesFields(t *testing.T) {
+	// Fields are spilled to disk and read back unchanged.
+	rows := [][]string{
+		[]string{"b", "line\r\nbreak"},
+		[]string{"a", "\"quoted\", \r"},
+		[]string{"c", ""},
+	}
+	sorter := NewExternalSorter([]SortColumn{{index: 0}}, true, 1)
+	for _, row := range rows {
+		err := sorter.Add(row)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+	}
+	toc := new(testOutputCsv)
+	err := sorter.WriteSorted(toc)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := [][]string{rows[1], rows[0], rows[2]}
+	err = assertRowsEqual(expected, toc.rows)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSortCsvColumnOptions(t *testing.T) {
+	testCases := []struct {
+		columns string
+		rows    [][]string
+	}{
+		{"Region:asc,Amount:desc", [][]string{
+			[]string{"Name", "Region", "Amount"},
+			[]string{"file2", "East", "3"},
+			[]string{"File2", "East", ""},
+			[]string{"file1", "West", "10"},
+			[]string{"file10", "West", "5"},
+			[]string{"", "West", "5"},
+		}},
+		{"Amount:nulls-last", [][]string{
+			[]string{"Name", "Region", "Amount"},
+			[]string{"file2", "East", "3"},
+			[]string{"file10", "West", "5"},
+			[]string{"", "West", "5"},
+			[]string{"file1", "West", "10"},
+			[]string{"File2", "East", ""},
+		}},
+		{"Name:natural", [][]string{
+			[]string{"Name", "Region", "Amount"},
+			[]string{"", "West", "5"},
+			[]string{"File2", "East", ""},
+			[]string{"file1", "West", "10"},
+			[]string{"file2", "East", "3"},
+			[]string{"file10", "West", "5"},
+		}},
+		{"Name:natural:nocase", [][]string{
+			[]string{"Name", "Region", "Amount"},
+			[]string{"", "West", "5"},
+			[]string{"file1", "West", "10"},
+			[]string{"File2", "East", ""},
+			[]string{"file2", "East", "3"},
+			[]string{"file10", "West", "5"},
+		}},
+		{"Name:nocase", [][]string{
+			[]string{"Name", "Region", "Amount"},
+			[]string{"", "West", "5"},
+			[]string{"file1", "West", "10"},
+			[]string{"file10", "West", "5"},
+			[]string{"File2", "East", ""},
+			[]string{"file2", "East", "3"},
+		}},
+		{"Name:desc:nulls-first", [][]string{
+			[]string{"Name", "Region", "Amount"},
+			[]string{"", "West", "5"},
+			[]string{"file2", "East", "3"},
+			[]string{"file10", "West", "5"},
+			[]string{"file1", "West", "10"},
+			[]string{"File2", "East", ""},
+		}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.columns, func(t *testing.T) {
+			ic, err := NewInputCsv("../test-files/sort-options.csv")
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(SortSubcommand)
+			sub.columnsString = tt.columns
+			sub.SortCsv(ic, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		cmp  int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file02", "file2", -1},
+		{"file2", "file2", 0},
+		{"a", "a1", -1},
+		{"x10y2", "x10y10", -1},
+		{"abc", "abd", -1},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			cmp := CompareNatural(tt.a, tt.b)
+			if cmp != tt.cmp {
+				t.Errorf("Expected %d but got %d", tt.cmp, cmp)
+			}
+		})
+	}
+}
+
+func TestSortCsvNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	var buf bytes.Buffer
+	sub := new(SortSubcommand)
+	sub.columnsString = "2"
+	sub.SortCsv(ic, NewOutputCsvFromWriter(&buf))
+	expected := "2,Another Two\n-1,Minus One\n1,One\nNumber,String\n2,Two\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
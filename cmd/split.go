@@ -1,17 +1,25 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
-	"fmt"
 	"io"
-	"os"
-	"strconv"
 	"strings"
 )
 
 type SplitSubcommand struct {
-	maxRows      int
-	filenameBase string
+	maxRows          int
+	maxBytesStr      string
+	numParts         int
+	byString         string
+	filenameBase     string
+	filenameTemplate string
+	maxOpenFiles     int
+	compression      string
+	ratiosString     string
+	stratifyString   string
+	groupString      string
+	seed             int
 }
 
 func (sub *SplitSubcommand) Name() string {
@@ -25,72 +33,229 @@ func (sub *SplitSubcommand) Description() string {
 }
 func (sub *SplitSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.IntVar(&sub.maxRows, "max-rows", 0, "Maximum number of rows per CSV.")
+	fs.StringVar(&sub.maxBytesStr, "max-bytes", "", "Maximum size of each CSV, e.g. 100MB.")
+	fs.IntVar(&sub.numParts, "parts", 0, "Number of CSVs of even numbers of rows to split into.")
+	fs.StringVar(&sub.byString, "by", "", "Columns whose values determine the CSV each row is written to.")
 	fs.StringVar(&sub.filenameBase, "filename-base", "", "Base of filenames for output.")
+	fs.StringVar(&sub.filenameTemplate, "filename-template", "", "Template of filenames for output, e.g. {base}-{value}-{n}.csv.")
+	fs.IntVar(&sub.maxOpenFiles, "max-open-files", DEFAULT_MAX_OPEN_FILES, "Maximum number of output files to keep open at once.")
+	fs.StringVar(&sub.compression, "output-compression", "", "Compression format of output files: gzip or zlib.")
+	fs.StringVar(&sub.ratiosString, "ratios", "", "Comma-separated ratios of rows for train, test and validation CSVs.")
+	fs.StringVar(&sub.stratifyString, "stratify", "", "Columns whose values should be split in the same ratios.")
+	fs.StringVar(&sub.groupString, "group", "", "Columns whose values should not be split across CSVs.")
+	fs.IntVar(&sub.seed, "seed", 0, "Seed for random number generation.")
 }
 
 func (sub *SplitSubcommand) Run(args []string) {
-	if sub.maxRows < 1 {
-		fmt.Fprintln(os.Stderr, "Invalid parameter for --max-rows")
-		os.Exit(1)
-	}
-
 	inputCsvs := GetInputCsvsOrPanic(args, 1)
-	Split(inputCsvs[0], sub.maxRows, sub.filenameBase)
+	sub.RunSplit(inputCsvs[0])
 }
 
-func Split(inputCsv *InputCsv, maxRows int, filenameBase string) {
-	if filenameBase == "" {
-		inputFilename := inputCsv.Filename()
-		if inputFilename == "-" {
-			filenameBase = "out"
+func (sub *SplitSubcommand) RunSplit(inputCsv *InputCsv) {
+	var maxBytes int64
+	if sub.maxBytesStr != "" {
+		var err error
+		maxBytes, err = ParseByteSize(sub.maxBytesStr)
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
+	if sub.maxRows < 0 {
+		ExitWithError(errors.New("Invalid parameter for --max-rows"))
+	}
+	if sub.numParts < 0 {
+		ExitWithError(errors.New("Invalid parameter for --parts"))
+	}
+	hasSizeLimit := sub.maxRows > 0 || maxBytes > 0
+	compression, err := ParseOutputCompression(sub.compression)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	// Determine the mode and its default filename template.
+	var ratios []float64
+	var template string
+	if sub.ratiosString != "" {
+		if hasSizeLimit || sub.numParts > 0 || sub.byString != "" {
+			ExitWithError(errors.New("Cannot combine --ratios with other ways of splitting"))
+		}
+		var err error
+		ratios, err = ParseSplitRatios(sub.ratiosString)
+		if err != nil {
+			ExitWithError(err)
+		}
+		template = "{base}-{value}.csv"
+	} else if sub.numParts > 0 {
+		if hasSizeLimit || sub.byString != "" {
+			ExitWithError(errors.New("Cannot combine --parts with other ways of splitting"))
+		}
+		template = "{base}-{n}.csv"
+	} else if sub.byString != "" {
+		if hasSizeLimit {
+			template = "{base}-{value}-{n}.csv"
 		} else {
-			fileParts := strings.Split(inputFilename, ".")
-			filenameBase = strings.Join(fileParts[:len(fileParts)-1], ".")
+			template = "{base}-{value}.csv"
+		}
+	} else if hasSizeLimit {
+		template = "{base}-{n}.csv"
+	} else {
+		ExitWithError(errors.New("Missing argument --max-rows, --max-bytes, --parts, --by or --ratios"))
+	}
+
+	if sub.filenameTemplate != "" {
+		template = sub.filenameTemplate
+		if (sub.byString != "" || ratios != nil) && !strings.Contains(template, "{value}") {
+			ExitWithError(errors.New("Filename template must contain {value}"))
+		}
+		if (hasSizeLimit || sub.numParts > 0) && !strings.Contains(template, "{n}") {
+			ExitWithError(errors.New("Filename template must contain {n}"))
 		}
 	}
 
-	// Read and write header.
 	header, err := inputCsv.Read()
 	if err != nil {
 		ExitWithError(err)
 	}
+	sw := NewSplitWriter(inputCsv, header, GetSplitFilenameBase(inputCsv, sub.filenameBase), template)
+	sw.maxRows = sub.maxRows
+	sw.maxBytes = maxBytes
+	sw.maxOpenFiles = sub.maxOpenFiles
+	sw.compression = compression
 
-	fileNumber := 1
-	numRowsWritten := 0
-	curFilename := filenameBase + "-" + strconv.Itoa(fileNumber) + ".csv"
-	curFile, err := os.Create(curFilename)
+	if ratios != nil {
+		err = SplitByRatios(inputCsv, sw, ratios, getColumnsOrEmpty(sub.stratifyString), getColumnsOrEmpty(sub.groupString), NewSampleRand(sub.seed))
+	} else if sub.numParts > 0 {
+		err = SplitIntoParts(inputCsv, sw, sub.numParts)
+	} else {
+		err = SplitRows(inputCsv, sw, getColumnsOrEmpty(sub.byString))
+	}
 	if err != nil {
 		ExitWithError(err)
 	}
-	defer curFile.Close()
+	err = sw.Close()
+	if err != nil {
+		ExitWithError(err)
+	}
+}
 
-	outputCsv := NewFileOutputCsvFromInputCsv(inputCsv, curFile)
-	outputCsv.Write(header)
+// Split splits a CSV into files of at most maxRows rows each, named
+// filenameBase followed by "-1.csv", "-2.csv", etc.
+func Split(inputCsv *InputCsv, maxRows int, filenameBase string) {
+	sub := &SplitSubcommand{
+		maxRows:      maxRows,
+		filenameBase: filenameBase,
+		maxOpenFiles: DEFAULT_MAX_OPEN_FILES,
+	}
+	sub.RunSplit(inputCsv)
+}
 
+// SplitRows writes the rows of a CSV, after the header, to a SplitWriter.
+// If there are by columns, each row is written to the file for its values
+// in those columns, which is named after the values joined by "-".
+func SplitRows(inputCsv *InputCsv, sw *SplitWriter, byColumns []string) error {
+	var byIndices []int
+	if len(byColumns) > 0 {
+		var err error
+		byIndices, err = GetIndicesForColumns(sw.header, byColumns)
+		if err != nil {
+			return err
+		}
+	}
+	values := make([]string, len(byIndices))
 	for {
 		row, err := inputCsv.Read()
 		if err != nil {
 			if err == io.EOF {
-				break
-			} else {
-				ExitWithError(err)
+				return nil
+			}
+			return err
+		}
+		for i, index := range byIndices {
+			values[i] = row[index]
+		}
+		err = sw.WriteKey(GetRowKey(row, byIndices), strings.Join(values, "-"), row)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// SplitIntoParts splits the rows of a CSV, after the header, into numParts
+// files of consecutive rows, whose numbers of rows differ by at most one.
+// Files are read twice to count the rows, while standard input is held
+// in memory.
+func SplitIntoParts(inputCsv *InputCsv, sw *SplitWriter, numParts int) error {
+	var rows [][]string
+	var numRows int
+	if inputCsv.Filename() != "-" {
+		numRecords, err := countRows(inputCsv)
+		if err != nil {
+			return err
+		}
+		numRows = numRecords - 1
+	} else {
+		var err error
+		rows, err = inputCsv.ReadAll()
+		if err != nil {
+			return err
+		}
+		numRows = len(rows)
+	}
+
+	// Create every part, even if there are fewer rows than parts.
+	err := sw.Create("")
+	if err != nil {
+		return err
+	}
+	part := 0
+	for rowIndex := 0; rowIndex < numRows; rowIndex++ {
+		var row []string
+		if rows != nil {
+			row = rows[rowIndex]
+		} else {
+			row, err = inputCsv.Read()
+			if err != nil {
+				return err
 			}
 		}
-		// Switch to the next file.
-		if numRowsWritten == maxRows {
-			fileNumber++
-			numRowsWritten = 0
-			curFilename = filenameBase + "-" + strconv.Itoa(fileNumber) + ".csv"
-			curFile, err = os.Create(curFilename)
+		for ; part < rowIndex*numParts/numRows; part++ {
+			err = sw.NextFile("")
 			if err != nil {
-				ExitWithError(err)
+				return err
 			}
-			defer curFile.Close()
-			outputCsv = NewFileOutputCsvFromInputCsv(inputCsv, curFile)
-			outputCsv.Write(header)
 		}
+		err = sw.Write("", row)
+		if err != nil {
+			return err
+		}
+	}
+	for ; part < numParts-1; part++ {
+		err = sw.NextFile("")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSplitFilenameBase returns the base of the filenames of split CSVs. If
+// filenameBase is empty, it is the input filename without its extension,
+// or "out" for standard input.
+func GetSplitFilenameBase(inputCsv *InputCsv, filenameBase string) string {
+	if filenameBase != "" {
+		return filenameBase
+	}
+	inputFilename := inputCsv.Filename()
+	if inputFilename == "-" {
+		return "out"
+	}
+	fileParts := strings.Split(TrimCompressionExtension(inputFilename), ".")
+	return strings.Join(fileParts[:len(fileParts)-1], ".")
+}
 
-		outputCsv.Write(row)
-		numRowsWritten++
+func getColumnsOrEmpty(columnsString string) []string {
+	if columnsString == "" {
+		return make([]string, 0)
 	}
+	return GetArrayFromCsvString(columnsString)
 }
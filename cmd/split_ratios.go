@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// SPLIT_RATIO_NAMES are the names of the CSVs split into by ratio, which
+// are used as the values in their filenames.
+var SPLIT_RATIO_NAMES = []string{"train", "test", "validation"}
+
+// ParseSplitRatios parses a comma-separated list of two or three ratios,
+// which must add up to 1.
+func ParseSplitRatios(ratiosString string) ([]float64, error) {
+	ratioStrings := strings.Split(ratiosString, ",")
+	if len(ratioStrings) < 2 || len(ratioStrings) > len(SPLIT_RATIO_NAMES) {
+		return nil, errors.New("Invalid argument --ratios: must have 2 or 3 ratios")
+	}
+	ratios := make([]float64, len(ratioStrings))
+	total := 0.0
+	for i, ratioString := range ratioStrings {
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(ratioString), 64)
+		if err != nil || ratio < 0 {
+			return nil, errors.New("Invalid argument --ratios: " + ratioString)
+		}
+		ratios[i] = ratio
+		total += ratio
+	}
+	if math.Abs(total-1) > 1e-6 {
+		return nil, errors.New("Invalid argument --ratios: ratios must add up to 1")
+	}
+	return ratios, nil
+}
+
+// splitUnit is a set of rows that are always assigned to the same split:
+// either a single row or all of the rows of a group.
+type splitUnit struct {
+	rowIndices []int
+}
+
+// SplitByRatios randomly splits the rows of a CSV into train, test and
+// (optionally) validation CSVs in the given ratios. The number of rows in
+// each split is the ratio of the total rounded to the nearest row.
+//
+// If there are stratify columns, the rows with each combination of values
+// in those columns are split separately, so that each combination appears
+// in every split in the same ratios. If there are group columns, all of the
+// rows with the same values in those columns are assigned to the same split,
+// so the ratios are only approximate. When both are given, a group is
+// stratified by the values in its first row.
+//
+// The rows after the header are read from the input and written to the
+// SplitWriter with the names of the splits as values. All of the rows are
+// held in memory, and each split is written in the order of the input.
+func SplitByRatios(inputCsv *InputCsv, sw *SplitWriter, ratios []float64, stratifyColumns, groupColumns []string, rng *rand.Rand) error {
+	// An empty list of columns would otherwise resolve to every column.
+	stratifyIndices := make([]int, 0)
+	groupIndices := make([]int, 0)
+	var err error
+	if len(stratifyColumns) > 0 {
+		stratifyIndices, err = GetIndicesForColumns(sw.header, stratifyColumns)
+		if err != nil {
+			return err
+		}
+	}
+	if len(groupColumns) > 0 {
+		groupIndices, err = GetIndicesForColumns(sw.header, groupColumns)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows, err := inputCsv.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	// Collect the units in each stratum, in order of first appearance.
+	strata := make(map[string][]*splitUnit)
+	stratumKeys := make([]string, 0)
+	groups := make(map[string]*splitUnit)
+	for rowIndex, row := range rows {
+		var unit *splitUnit
+		if len(groupIndices) > 0 {
+			groupKey := GetRowKey(row, groupIndices)
+			if group, ok := groups[groupKey]; ok {
+				group.rowIndices = append(group.rowIndices, rowIndex)
+				continue
+			}
+			unit = &splitUnit{rowIndices: []int{rowIndex}}
+			groups[groupKey] = unit
+		} else {
+			unit = &splitUnit{rowIndices: []int{rowIndex}}
+		}
+		stratumKey := GetRowKey(row, stratifyIndices)
+		if _, ok := strata[stratumKey]; !ok {
+			stratumKeys = append(stratumKeys, stratumKey)
+		}
+		strata[stratumKey] = append(strata[stratumKey], unit)
+	}
+
+	rowSplits := make([]int, len(rows))
+	for _, stratumKey := range stratumKeys {
+		assignSplits(strata[stratumKey], ratios, rowSplits, rng)
+	}
+
+	// Create every split, even if it has no rows.
+	for i := range ratios {
+		err = sw.Create(SPLIT_RATIO_NAMES[i])
+		if err != nil {
+			return err
+		}
+	}
+	for rowIndex, row := range rows {
+		err = sw.Write(SPLIT_RATIO_NAMES[rowSplits[rowIndex]], row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignSplits shuffles the units and assigns each to a split according to
+// the number of rows that precede it, so that the splits are contiguous
+// ranges of the shuffled rows.
+func assignSplits(units []*splitUnit, ratios []float64, rowSplits []int, rng *rand.Rand) {
+	rng.Shuffle(len(units), func(i, j int) {
+		units[i], units[j] = units[j], units[i]
+	})
+
+	numRows := 0
+	for _, unit := range units {
+		numRows += len(unit.rowIndices)
+	}
+	boundaries := make([]int, len(ratios))
+	cumulativeRatio := 0.0
+	for i, ratio := range ratios {
+		cumulativeRatio += ratio
+		boundaries[i] = int(math.Round(cumulativeRatio * float64(numRows)))
+	}
+	boundaries[len(boundaries)-1] = numRows
+
+	position := 0
+	split := 0
+	for _, unit := range units {
+		for split < len(boundaries)-1 && position >= boundaries[split] {
+			split++
+		}
+		for _, rowIndex := range unit.rowIndices {
+			rowSplits[rowIndex] = split
+		}
+		position += len(unit.rowIndices)
+	}
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readSplitFile(t *testing.T, filename string) [][]string {
+	t.Helper()
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer ic.Close()
+	rows, err := ic.ReadAll()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	return rows
+}
+
+func TestSplit(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-split-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	Split(ic, 3, filepath.Join(tmpDir, "simple"))
+
+	expected := [][][]string{
+		{
+			{"Number", "String"},
+			{"1", "One"},
+			{"2", "Two"},
+			{"-1", "Minus One"},
+		},
+		{
+			{"Number", "String"},
+			{"2", "Another Two"},
+		},
+	}
+	for i, rows := range expected {
+		err = assertRowsEqual(rows, readSplitFile(t, filepath.Join(tmpDir, fmt.Sprintf("simple-%d.csv", i+1))))
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSplitByRatios(t *testing.T) {
+	testCases := []struct {
+		ratiosString   string
+		stratifyString string
+		groupString    string
+		numRows        []int
+		labelCounts    []map[string]int
+	}{
+		{"0.5,0.25,0.25", "", "", []int{10, 5, 5}, nil},
+		{"0.8,0.2", "", "", []int{16, 4}, nil},
+		{"0.5,0.5", "Label", "", []int{10, 10}, []map[string]int{
+			{"a": 6, "b": 3, "c": 1},
+			{"a": 6, "b": 3, "c": 1},
+		}},
+		{"0.5,0.25,0.25", "", "Label", nil, nil},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ratios, err := ParseSplitRatios(tt.ratiosString)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			runSplit := func() [][][]string {
+				tmpDir, err := ioutil.TempDir("", "gocsv-split-")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(tmpDir)
+				ic, err := NewInputCsv("../test-files/sample.csv")
+				if err != nil {
+					t.Fatal("Unexpected error", err)
+				}
+				sub := &SplitSubcommand{
+					filenameBase:   filepath.Join(tmpDir, "sample"),
+					ratiosString:   tt.ratiosString,
+					stratifyString: tt.stratifyString,
+					groupString:    tt.groupString,
+					seed:           42,
+				}
+				sub.RunSplit(ic)
+				splits := make([][][]string, len(ratios))
+				for j := range ratios {
+					splits[j] = readSplitFile(t, filepath.Join(tmpDir, "sample-"+SPLIT_RATIO_NAMES[j]+".csv"))
+				}
+				return splits
+			}
+			splits := runSplit()
+
+			seenIds := make(map[string]bool)
+			labelSplits := make(map[string]int)
+			for j, rows := range splits {
+				err = assertRowsEqual([][]string{{"ID", "Label"}}, rows[:1])
+				if err != nil {
+					t.Error(err)
+				}
+				if tt.numRows != nil && len(rows)-1 != tt.numRows[j] {
+					t.Errorf("Expected %d rows in split %d but got %d", tt.numRows[j], j, len(rows)-1)
+				}
+				labelCounts := make(map[string]int)
+				for _, row := range rows[1:] {
+					if seenIds[row[0]] {
+						t.Errorf("Row %s is in more than one split", row[0])
+					}
+					seenIds[row[0]] = true
+					labelCounts[row[1]]++
+					if tt.groupString != "" {
+						if split, ok := labelSplits[row[1]]; ok && split != j {
+							t.Errorf("Group %s is in more than one split", row[1])
+						}
+						labelSplits[row[1]] = j
+					}
+				}
+				if tt.labelCounts != nil {
+					for label, count := range tt.labelCounts[j] {
+						if labelCounts[label] != count {
+							t.Errorf("Expected %d rows with label %s in split %d but got %d", count, label, j, labelCounts[label])
+						}
+					}
+				}
+			}
+			if len(seenIds) != 20 {
+				t.Errorf("Expected all 20 rows to be split but got %d", len(seenIds))
+			}
+
+			// The same seed gives the same splits.
+			for j, rows := range runSplit() {
+				err = assertRowsEqual(splits[j], rows)
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSplitRatiosErrors(t *testing.T) {
+	for _, ratiosString := range []string{"1", "0.5,0.6", "0.5,abc", "0.25,0.25,0.25,0.25", "-0.5,1.5"} {
+		_, err := ParseSplitRatios(ratiosString)
+		if err == nil {
+			t.Errorf("Expected error for ratios %s", ratiosString)
+		}
+	}
+}
+
+func TestRunSplit(t *testing.T) {
+	testCases := []struct {
+		sub   SplitSubcommand
+		files map[string][][]string
+	}{
+		{SplitSubcommand{byString: "Label"}, map[string][][]string{
+			"sample-a.csv": {{"ID", "Label"}, {"1", "a"}, {"2", "a"}, {"5", "a"}, {"6", "a"}, {"8", "a"}, {"10", "a"}, {"11", "a"}, {"15", "a"}, {"16", "a"}, {"17", "a"}, {"18", "a"}, {"20", "a"}},
+			"sample-b.csv": {{"ID", "Label"}, {"3", "b"}, {"7", "b"}, {"9", "b"}, {"12", "b"}, {"13", "b"}, {"14", "b"}},
+			"sample-c.csv": {{"ID", "Label"}, {"4", "c"}, {"19", "c"}},
+		}},
+		// Only one file open at a time, so files are closed and reopened.
+		{SplitSubcommand{byString: "Label", maxRows: 4, maxOpenFiles: 1}, map[string][][]string{
+			"sample-a-1.csv": {{"ID", "Label"}, {"1", "a"}, {"2", "a"}, {"5", "a"}, {"6", "a"}},
+			"sample-a-2.csv": {{"ID", "Label"}, {"8", "a"}, {"10", "a"}, {"11", "a"}, {"15", "a"}},
+			"sample-a-3.csv": {{"ID", "Label"}, {"16", "a"}, {"17", "a"}, {"18", "a"}, {"20", "a"}},
+			"sample-b-1.csv": {{"ID", "Label"}, {"3", "b"}, {"7", "b"}, {"9", "b"}, {"12", "b"}},
+			"sample-b-2.csv": {{"ID", "Label"}, {"13", "b"}, {"14", "b"}},
+			"sample-c-1.csv": {{"ID", "Label"}, {"4", "c"}, {"19", "c"}},
+		}},
+		// Each file has the 9 byte header and rows of 4 or 5 bytes.
+		{SplitSubcommand{maxBytesStr: "30B"}, map[string][][]string{
+			"sample-1.csv": {{"ID", "Label"}, {"1", "a"}, {"2", "a"}, {"3", "b"}, {"4", "c"}, {"5", "a"}},
+			"sample-2.csv": {{"ID", "Label"}, {"6", "a"}, {"7", "b"}, {"8", "a"}, {"9", "b"}, {"10", "a"}},
+			"sample-3.csv": {{"ID", "Label"}, {"11", "a"}, {"12", "b"}, {"13", "b"}, {"14", "b"}},
+			"sample-4.csv": {{"ID", "Label"}, {"15", "a"}, {"16", "a"}, {"17", "a"}, {"18", "a"}},
+			"sample-5.csv": {{"ID", "Label"}, {"19", "c"}, {"20", "a"}},
+		}},
+		{SplitSubcommand{numParts: 3, filenameTemplate: "part{n}-{base}.csv"}, map[string][][]string{
+			"part1-sample.csv": {{"ID", "Label"}, {"1", "a"}, {"2", "a"}, {"3", "b"}, {"4", "c"}, {"5", "a"}, {"6", "a"}, {"7", "b"}},
+			"part2-sample.csv": {{"ID", "Label"}, {"8", "a"}, {"9", "b"}, {"10", "a"}, {"11", "a"}, {"12", "b"}, {"13", "b"}, {"14", "b"}},
+			"part3-sample.csv": {{"ID", "Label"}, {"15", "a"}, {"16", "a"}, {"17", "a"}, {"18", "a"}, {"19", "c"}, {"20", "a"}},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			tmpDir, err := ioutil.TempDir("", "gocsv-split-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpDir)
+			ic, err := NewInputCsv("../test-files/sample.csv")
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			sub := tt.sub
+			sub.filenameBase = filepath.Join(tmpDir, "sample")
+			if sub.maxOpenFiles == 0 {
+				sub.maxOpenFiles = DEFAULT_MAX_OPEN_FILES
+			}
+			if sub.filenameTemplate != "" {
+				sub.filenameTemplate = filepath.Join(tmpDir, sub.filenameTemplate)
+				sub.filenameBase = "sample"
+			}
+			sub.RunSplit(ic)
+
+			files, err := ioutil.ReadDir(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.files) {
+				t.Errorf("Expected %d files but got %d", len(tt.files), len(files))
+			}
+			for filename, rows := range tt.files {
+				err = assertRowsEqual(rows, readSplitFile(t, filepath.Join(tmpDir, filename)))
+				if err != nil {
+					t.Error(filename, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	testCases := []struct {
+		value     string
+		sanitized string
+	}{
+		{"United States", "United_States"},
+		{"a/b\\c", "a_b_c"},
+		{"../etc", ".._etc"},
+		{"..", "__"},
+		{"", "_"},
+		{"Zürich-1.5", "Zürich-1.5"},
+	}
+	for _, tt := range testCases {
+		sanitized := SanitizeFilename(tt.value)
+		if sanitized != tt.sanitized {
+			t.Errorf("Expected %q for %q but got %q", tt.sanitized, tt.value, sanitized)
+		}
+	}
+}
+
+func TestSplitWriterFilenameCollisions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-split-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	ic, err := NewInputCsv("../test-files/sample.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	sw := NewSplitWriter(ic, []string{"Value"}, filepath.Join(tmpDir, "out"), "{base}-{value}.csv")
+	for _, value := range []string{"a b", "a_b", "a/b", "a b"} {
+		err = sw.Write(value, []string{value})
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+	}
+	err = sw.Close()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := map[string][][]string{
+		"out-a_b.csv":   {{"Value"}, {"a b"}, {"a b"}},
+		"out-a_b_2.csv": {{"Value"}, {"a_b"}},
+		"out-a_b_3.csv": {{"Value"}, {"a/b"}},
+	}
+	for filename, rows := range expected {
+		err = assertRowsEqual(rows, readSplitFile(t, filepath.Join(tmpDir, filename)))
+		if err != nil {
+			t.Error(filename, err)
+		}
+	}
+}
+
+func TestSplitRowsJoinedValueCollisions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-split-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "in.csv")
+	err = ioutil.WriteFile(filename, []byte("A,B\na-b,c\na,b-c\na-b,c\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	header, err := ic.Read()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	sw := NewSplitWriter(ic, header, filepath.Join(tmpDir, "out"), "{base}-{value}.csv")
+	err = SplitRows(ic, sw, []string{"A", "B"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err = sw.Close()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	// Both rows join to "a-b-c", but have different values.
+	expected := map[string][][]string{
+		"out-a-b-c.csv":   {{"A", "B"}, {"a-b", "c"}, {"a-b", "c"}},
+		"out-a-b-c_2.csv": {{"A", "B"}, {"a", "b-c"}},
+	}
+	for filename, rows := range expected {
+		err = assertRowsEqual(rows, readSplitFile(t, filepath.Join(tmpDir, filename)))
+		if err != nil {
+			t.Error(filename, err)
+		}
+	}
+}
+
+func TestRunSplitNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	tmpDir, err := ioutil.TempDir("", "gocsv-split-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	sub := SplitSubcommand{maxBytesStr: "27B", filenameBase: filepath.Join(tmpDir, "out"), maxOpenFiles: DEFAULT_MAX_OPEN_FILES}
+	sub.RunSplit(ic)
+
+	// No file starts with a header, and the size of each file doesn't
+	// include one.
+	expected := map[string]string{
+		"out-1.csv": "Number,String\n1,One\n2,Two\n",
+		"out-2.csv": "-1,Minus One\n2,Another Two\n",
+	}
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(expected) {
+		t.Errorf("Expected %d files but got %d", len(expected), len(files))
+	}
+	for filename, contents := range expected {
+		actual, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != contents {
+			t.Errorf("Expected %q in %s but got %q", contents, filename, string(actual))
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DEFAULT_MAX_OPEN_FILES is the default number of files a SplitWriter
+// keeps open at once.
+const DEFAULT_MAX_OPEN_FILES = 256
+
+// SplitWriter writes rows to the CSVs a CSV is split into. Each row is
+// written with a value, such as the value of a column, and rows with
+// different values are written to different files. The rows for a value
+// move on to a new numbered file once the current file reaches a maximum
+// number of rows or bytes.
+//
+// Filenames are made from a template in which "{base}" is replaced by the
+// base filename, "{value}" by the value (sanitized to be safe in a filename)
+// and "{n}" by the number of the file for that value, starting from 1.
+//
+// Files are compressed in the compression format, if any, or else in the
+// format indicated by the extension of the filename.
+//
+// At most maxOpenFiles files are kept open. When more are needed, the least
+// recently written file is closed and reopened for appending if any more
+// rows are written to it.
+type SplitWriter struct {
+	inputCsv     *InputCsv
+	header       []string
+	filenameBase string
+	template     string
+	maxRows      int
+	maxBytes     int64
+	maxOpenFiles int
+	compression  string
+
+	outputs       map[string]*splitOutput
+	usedFilenames map[string]bool
+	openOutputs   []*splitOutput
+	numWrites     int64
+	sizeBuffer    bytes.Buffer
+}
+
+type splitOutput struct {
+	filenameValue string
+	fileNumber    int
+	filename      string
+	numRows       int
+	numBytes      int64
+	lastWrite     int64
+	compression   string
+	file          io.WriteCloser
+	outputCsv     *OutputCsv
+}
+
+func NewSplitWriter(inputCsv *InputCsv, header []string, filenameBase, template string) *SplitWriter {
+	return &SplitWriter{
+		inputCsv:      inputCsv,
+		header:        header,
+		filenameBase:  filenameBase,
+		template:      template,
+		maxOpenFiles:  DEFAULT_MAX_OPEN_FILES,
+		outputs:       make(map[string]*splitOutput),
+		usedFilenames: make(map[string]bool),
+	}
+}
+
+// Create makes sure that the file for a value exists, even if no rows
+// are written for it.
+func (sw *SplitWriter) Create(value string) error {
+	_, err := sw.getOutput(value, value)
+	return err
+}
+
+// Write writes a row to the file for a value.
+func (sw *SplitWriter) Write(value string, row []string) error {
+	return sw.WriteKey(value, value, row)
+}
+
+// WriteKey writes a row to the file for a key, which is named after value.
+// Rows with different keys are written to different files, even if their
+// values are the same.
+func (sw *SplitWriter) WriteKey(key, value string, row []string) error {
+	so, err := sw.getOutput(key, value)
+	if err != nil {
+		return err
+	}
+	var rowSize int64
+	if sw.maxBytes > 0 {
+		rowSize = sw.encodedSize(row)
+	}
+	isFull := (sw.maxRows > 0 && so.numRows >= sw.maxRows) ||
+		(sw.maxBytes > 0 && so.numRows > 0 && so.numBytes+rowSize > sw.maxBytes)
+	if isFull {
+		err = sw.nextFile(so)
+		if err != nil {
+			return err
+		}
+	}
+	if so.file == nil {
+		err = sw.reopenFile(so)
+		if err != nil {
+			return err
+		}
+	}
+	sw.numWrites++
+	so.lastWrite = sw.numWrites
+	err = so.outputCsv.Write(row)
+	if err != nil {
+		return err
+	}
+	so.numRows++
+	so.numBytes += rowSize
+	return nil
+}
+
+// NextFile moves the rows for a value on to a new numbered file.
+func (sw *SplitWriter) NextFile(value string) error {
+	so, err := sw.getOutput(value, value)
+	if err != nil {
+		return err
+	}
+	return sw.nextFile(so)
+}
+
+func (sw *SplitWriter) nextFile(so *splitOutput) error {
+	err := sw.closeOutput(so)
+	if err != nil {
+		return err
+	}
+	so.fileNumber++
+	return sw.createFile(so)
+}
+
+// Close closes all of the open files.
+func (sw *SplitWriter) Close() error {
+	var firstErr error
+	for len(sw.openOutputs) > 0 {
+		err := sw.closeOutput(sw.openOutputs[0])
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (sw *SplitWriter) getOutput(key, value string) (*splitOutput, error) {
+	so, ok := sw.outputs[key]
+	if ok {
+		return so, nil
+	}
+	so = &splitOutput{
+		filenameValue: sw.uniqueFilenameValue(value),
+		fileNumber:    1,
+	}
+	sw.outputs[key] = so
+	err := sw.createFile(so)
+	if err != nil {
+		return nil, err
+	}
+	return so, nil
+}
+
+// uniqueFilenameValue sanitizes a value for use in filenames, adding a
+// numeric suffix if a different value was sanitized to the same string.
+func (sw *SplitWriter) uniqueFilenameValue(value string) string {
+	sanitized := SanitizeFilename(value)
+	filenameValue := sanitized
+	for i := 2; sw.usedFilenames[filenameValue]; i++ {
+		filenameValue = sanitized + "_" + strconv.Itoa(i)
+	}
+	sw.usedFilenames[filenameValue] = true
+	return filenameValue
+}
+
+func (sw *SplitWriter) createFile(so *splitOutput) error {
+	err := sw.makeRoomForFile()
+	if err != nil {
+		return err
+	}
+	replacer := strings.NewReplacer(
+		"{base}", sw.filenameBase,
+		"{value}", so.filenameValue,
+		"{n}", strconv.Itoa(so.fileNumber),
+	)
+	so.filename = AddCompressionExtension(replacer.Replace(sw.template), sw.compression)
+	so.compression = CompressionFromFilename(so.filename)
+	so.file, err = CreateOutputFile(so.filename, so.compression, false)
+	if err != nil {
+		return err
+	}
+	so.outputCsv = NewOutputCsvFromInputCsvsAndWriter([]*InputCsv{sw.inputCsv}, so.file)
+	so.numRows = 0
+	so.numBytes = 0
+	// With --no-header, the header is not written.
+	if !so.outputCsv.skipHeader {
+		so.numBytes = sw.encodedSize(sw.header)
+	}
+	sw.openOutputs = append(sw.openOutputs, so)
+	return so.outputCsv.Write(sw.header)
+}
+
+func (sw *SplitWriter) reopenFile(so *splitOutput) error {
+	err := sw.makeRoomForFile()
+	if err != nil {
+		return err
+	}
+	so.file, err = CreateOutputFile(so.filename, so.compression, true)
+	if err != nil {
+		return err
+	}
+	so.outputCsv = NewOutputCsvFromInputCsvsAndWriter([]*InputCsv{sw.inputCsv}, so.file)
+	// The header, and any BOM, has already been written.
+	so.outputCsv.skipHeader = false
+	so.outputCsv.hasWrittenHeader = true
+	sw.openOutputs = append(sw.openOutputs, so)
+	return nil
+}
+
+// makeRoomForFile closes the least recently written file if the maximum
+// number of files are open.
+func (sw *SplitWriter) makeRoomForFile() error {
+	if sw.maxOpenFiles < 1 || len(sw.openOutputs) < sw.maxOpenFiles {
+		return nil
+	}
+	leastRecent := sw.openOutputs[0]
+	for _, so := range sw.openOutputs[1:] {
+		if so.lastWrite < leastRecent.lastWrite {
+			leastRecent = so
+		}
+	}
+	return sw.closeOutput(leastRecent)
+}
+
+func (sw *SplitWriter) closeOutput(so *splitOutput) error {
+	if so.file == nil {
+		return nil
+	}
+	for i, openOutput := range sw.openOutputs {
+		if openOutput == so {
+			sw.openOutputs = append(sw.openOutputs[:i], sw.openOutputs[i+1:]...)
+			break
+		}
+	}
+	err := so.file.Close()
+	so.file = nil
+	so.outputCsv = nil
+	return err
+}
+
+// encodedSize returns the number of bytes a row takes up in a CSV.
+func (sw *SplitWriter) encodedSize(row []string) int64 {
+	sw.sizeBuffer.Reset()
+	oc := NewOutputCsvFromWriter(&sw.sizeBuffer)
+	oc.writeRow(row)
+	return int64(sw.sizeBuffer.Len())
+}
+
+// SanitizeFilename replaces characters that are not safe in filenames
+// with underscores. Letters, digits, "-", "_" and "." are kept, but a
+// value made up only of dots is replaced, as is an empty value.
+func SanitizeFilename(value string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, value)
+	if sanitized == "" {
+		return "_"
+	}
+	if strings.Trim(sanitized, ".") == "" {
+		return strings.Repeat("_", len(sanitized))
+	}
+	return sanitized
+}
+
+// countRows reads a CSV again from the start to count its rows,
+// including the header.
+func countRows(inputCsv *InputCsv) (int, error) {
+	ic, err := inputCsv.Reopen()
+	if err != nil {
+		return 0, err
+	}
+	defer ic.Close()
+	numRows := 0
+	for {
+		_, err := ic.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return 0, err
+		}
+		numRows++
+	}
+	return numRows, nil
+}
@@ -11,6 +11,7 @@ import (
 
 type SqlSubcommand struct {
 	queryString string
+	inputFiles  InputFilesFlags
 }
 
 func (sub *SqlSubcommand) Name() string {
@@ -25,10 +26,11 @@ func (sub *SqlSubcommand) Description() string {
 func (sub *SqlSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.StringVar(&sub.queryString, "query", "", "SQL query")
 	fs.StringVar(&sub.queryString, "q", "", "SQL query (shorthand)")
+	sub.inputFiles.SetFlags(fs)
 }
 
 func (sub *SqlSubcommand) Run(args []string) {
-	inputCsvs := GetInputCsvsOrPanic(args, -1)
+	inputCsvs := GetInputCsvsOrPanic(sub.inputFiles.ExpandFilenamesOrPanic(args), -1)
 	outputCsv := NewOutputCsvFromInputCsvs(inputCsvs)
 	sub.RunSql(inputCsvs, outputCsv)
 }
@@ -3,13 +3,25 @@ package cmd
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"io"
+	"strconv"
+)
+
+const (
+	STACK_HEADERS_MATCH     = "match"
+	STACK_HEADERS_UNION     = "union"
+	STACK_HEADERS_INTERSECT = "intersect"
 )
 
 type StackSubcommand struct {
 	groupName    string
 	groupsString string
 	useFilenames bool
+	unionByName  bool
+	intersect    bool
+	fill         string
+	inputFiles   InputFilesFlags
 }
 
 func (sub *StackSubcommand) Name() string {
@@ -25,29 +37,34 @@ func (sub *StackSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.StringVar(&sub.groupName, "group-name", "", "Name of the column for grouping")
 	fs.StringVar(&sub.groupsString, "groups", "", "Group to display for each file")
 	fs.BoolVar(&sub.useFilenames, "filenames", false, "Use the filename for groups")
+	fs.BoolVar(&sub.unionByName, "union-by-name", false, "Align columns by name, keeping all columns")
+	fs.BoolVar(&sub.intersect, "intersect", false, "Align columns by name, keeping only columns in every file")
+	fs.StringVar(&sub.fill, "fill", "", "Value for columns missing from a file with --union-by-name")
+	sub.inputFiles.SetFlags(fs)
 }
 
 func (sub *StackSubcommand) Run(args []string) {
-	filenames := args
+	filenames := sub.inputFiles.ExpandFilenamesOrPanic(args)
 
 	hasSpecifiedGroups := sub.groupsString != ""
 	if hasSpecifiedGroups && sub.useFilenames {
 		ExitWithError(errors.New("Cannot specify both --filename and --groups"))
 	}
-
-	shouldAppendGroup := hasSpecifiedGroups || sub.useFilenames
-
-	var groups []string
-	if hasSpecifiedGroups {
-		groups = GetArrayFromCsvString(sub.groupsString)
-	} else if sub.useFilenames {
-		groups = filenames
+	if sub.unionByName && sub.intersect {
+		ExitWithError(errors.New("Cannot specify both --union-by-name and --intersect"))
 	}
-
-	if shouldAppendGroup && len(filenames) != len(groups) {
-		ExitWithError(errors.New("Number of files and groups are not equal"))
+	if sub.fill != "" && !sub.unionByName {
+		ExitWithError(errors.New("--fill requires --union-by-name"))
+	}
+	headerMode := STACK_HEADERS_MATCH
+	if sub.unionByName {
+		headerMode = STACK_HEADERS_UNION
+	} else if sub.intersect {
+		headerMode = STACK_HEADERS_INTERSECT
 	}
 
+	shouldAppendGroup := hasSpecifiedGroups || sub.useFilenames
+
 	var groupColumnName string
 	if sub.groupName != "" {
 		groupColumnName = sub.groupName
@@ -60,14 +77,51 @@ func (sub *StackSubcommand) Run(args []string) {
 	}
 
 	inputCsvs := GetInputCsvsOrPanic(filenames, -1)
-	StackFiles(inputCsvs, groupColumnName, groups)
+	groups, err := StackGroups(inputCsvs, sub.groupsString, sub.useFilenames)
+	if err != nil {
+		ExitWithError(err)
+	}
+	outputCsv := NewOutputCsvFromInputCsvs(inputCsvs)
+	StackFiles(inputCsvs, outputCsv, groupColumnName, groups, headerMode, sub.fill)
 }
 
-func StackFiles(inputCsvs []*InputCsv, groupName string, groups []string) {
+// StackGroups returns the group of each CSV: the comma-separated groups
+// given in groupsString, or the filenames of the CSVs if useFilenames is
+// true, or nil if there are neither. Since archives expand into one CSV for
+// each of their members, the groups are matched to the expanded CSVs, and
+// the filename of a member is its name within the archive.
+func StackGroups(inputCsvs []*InputCsv, groupsString string, useFilenames bool) ([]string, error) {
+	var groups []string
+	if groupsString != "" {
+		groups = GetArrayFromCsvString(groupsString)
+	} else if useFilenames {
+		groups = make([]string, len(inputCsvs))
+		for i, inputCsv := range inputCsvs {
+			groups[i] = inputCsv.Filename()
+			if _, member, ok := SplitArchiveMember(groups[i]); ok {
+				groups[i] = member
+			}
+		}
+	} else {
+		return nil, nil
+	}
+	if len(groups) != len(inputCsvs) {
+		return nil, fmt.Errorf("Number of files and groups are not equal: %d files and %d groups", len(inputCsvs), len(groups))
+	}
+	return groups, nil
+}
+
+// StackFiles writes the rows of each CSV in turn under a single header,
+// optionally appending a group column identifying the CSV of each row.
+//
+// With the STACK_HEADERS_MATCH header mode, the headers must be identical.
+// With STACK_HEADERS_UNION, columns are aligned by name and the header has
+// every column in order of first appearance, with missing columns filled
+// with fill. With STACK_HEADERS_INTERSECT, only the columns in every
+// header are kept, in the order of the first header.
+func StackFiles(inputCsvs []*InputCsv, outputCsvWriter OutputCsvWriter, groupName string, groups []string, headerMode string, fill string) {
 	shouldAppendGroup := groupName != ""
-	outputCsv := NewOutputCsvFromInputCsvs(inputCsvs)
 
-	// Check that the headers match
 	headers := make([][]string, len(inputCsvs))
 	for i, inputCsv := range inputCsvs {
 		header, err := inputCsv.Read()
@@ -76,24 +130,23 @@ func StackFiles(inputCsvs []*InputCsv, groupName string, groups []string) {
 		}
 		headers[i] = header
 	}
-	firstHeader := headers[0]
-	for i, header := range headers {
-		if i == 0 {
-			continue
-		}
-		if len(firstHeader) != len(header) {
-			ExitWithError(errors.New("Headers do not match"))
-		}
-		for j, elem := range firstHeader {
-			if elem != header[j] {
-				ExitWithError(errors.New("Headers do not match"))
-			}
+
+	var outputHeader []string
+	var columnMaps [][]int
+	if headerMode == STACK_HEADERS_MATCH {
+		// Check that the headers match
+		err := CheckHeadersMatch(headers)
+		if err != nil {
+			ExitWithError(err)
 		}
+		outputHeader = headers[0]
+	} else {
+		outputHeader, columnMaps = AlignHeaders(headers, headerMode == STACK_HEADERS_INTERSECT)
 	}
 	if shouldAppendGroup {
-		firstHeader = append(firstHeader, groupName)
+		outputHeader = append(outputHeader, groupName)
 	}
-	outputCsv.Write(firstHeader)
+	outputCsvWriter.Write(outputHeader)
 
 	// Go through the files
 	for i, inputCsv := range inputCsvs {
@@ -106,10 +159,102 @@ func StackFiles(inputCsvs []*InputCsv, groupName string, groups []string) {
 					ExitWithError(err)
 				}
 			}
+			if columnMaps != nil {
+				alignedRow := make([]string, len(columnMaps[i]))
+				for j, index := range columnMaps[i] {
+					if index == -1 || index >= len(row) {
+						alignedRow[j] = fill
+					} else {
+						alignedRow[j] = row[index]
+					}
+				}
+				row = alignedRow
+			}
 			if shouldAppendGroup {
 				row = append(row, groups[i])
 			}
-			outputCsv.Write(row)
+			outputCsvWriter.Write(row)
+		}
+	}
+}
+
+// AlignHeaders aligns the columns of headers by name. The aligned header
+// has every column in order of first appearance or, if intersect is true,
+// only the columns in every header in the order of the first header. For
+// each header, the column map has the index of each aligned column in that
+// header, or -1 if it is missing.
+//
+// If a header has a repeated column name, its n-th occurrence is aligned
+// with the n-th occurrence in the other headers.
+func AlignHeaders(headers [][]string, intersect bool) (alignedHeader []string, columnMaps [][]int) {
+	// Key each column by its name and occurrence.
+	keyedHeaders := make([][]string, len(headers))
+	for i, header := range headers {
+		occurrences := make(map[string]int)
+		keyedHeaders[i] = make([]string, len(header))
+		for j, column := range header {
+			occurrences[column]++
+			keyedHeaders[i][j] = strconv.Itoa(occurrences[column]) + ":" + column
+		}
+	}
+
+	alignedKeys := make([]string, 0)
+	alignedHeader = make([]string, 0)
+	keyCounts := make(map[string]int)
+	for _, keyedHeader := range keyedHeaders {
+		for _, key := range keyedHeader {
+			keyCounts[key]++
+		}
+	}
+	seenKeys := make(map[string]bool)
+	for i, keyedHeader := range keyedHeaders {
+		if intersect && i > 0 {
+			break
+		}
+		for j, key := range keyedHeader {
+			if seenKeys[key] || (intersect && keyCounts[key] < len(headers)) {
+				continue
+			}
+			seenKeys[key] = true
+			alignedKeys = append(alignedKeys, key)
+			alignedHeader = append(alignedHeader, headers[i][j])
+		}
+	}
+
+	columnMaps = make([][]int, len(headers))
+	for i, keyedHeader := range keyedHeaders {
+		keyIndices := make(map[string]int)
+		for j, key := range keyedHeader {
+			keyIndices[key] = j
+		}
+		columnMaps[i] = make([]int, len(alignedKeys))
+		for j, key := range alignedKeys {
+			index, ok := keyIndices[key]
+			if !ok {
+				index = -1
+			}
+			columnMaps[i][j] = index
+		}
+	}
+	return
+}
+
+// CheckHeadersMatch returns an error unless all of the headers
+// are identical and identically ordered.
+func CheckHeadersMatch(headers [][]string) error {
+	firstHeader := headers[0]
+	for i, header := range headers {
+		if i == 0 {
+			continue
+		}
+		if len(firstHeader) != len(header) {
+			return errors.New("Headers do not match")
+		}
+		for j, elem := range firstHeader {
+			if elem != header[j] {
+				return errors.New("Headers do not match")
+			}
 		}
 	}
+	return nil
 }
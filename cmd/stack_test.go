@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStackFiles(t *testing.T) {
+	testCases := []struct {
+		filenames  []string
+		groupName  string
+		groups     []string
+		headerMode string
+		fill       string
+		rows       [][]string
+	}{
+		{[]string{"stack-2.csv", "stack-3.csv"}, "", nil, STACK_HEADERS_MATCH, "", [][]string{
+			{"ID", "ABC"},
+			{"6", "Six"},
+			{"7", "Seven"},
+			{"8", "Eight"},
+			{"9", "Nine"},
+		}},
+		{[]string{"stack-2.csv", "stack-4.csv"}, "File", []string{"two", "four"}, STACK_HEADERS_UNION, "", [][]string{
+			{"ID", "ABC", "Extra", "File"},
+			{"6", "Six", "", "two"},
+			{"7", "Seven", "", "two"},
+			{"10", "Ten", "x", "four"},
+			{"11", "Eleven", "", "four"},
+		}},
+		{[]string{"stack-4.csv", "stack-2.csv"}, "", nil, STACK_HEADERS_UNION, "NA", [][]string{
+			{"ABC", "Extra", "ID"},
+			{"Ten", "x", "10"},
+			{"Eleven", "", "11"},
+			{"Six", "NA", "6"},
+			{"Seven", "NA", "7"},
+		}},
+		{[]string{"stack-2.csv", "stack-4.csv"}, "", nil, STACK_HEADERS_INTERSECT, "", [][]string{
+			{"ID", "ABC"},
+			{"6", "Six"},
+			{"7", "Seven"},
+			{"10", "Ten"},
+			{"11", "Eleven"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			inputCsvs := make([]*InputCsv, len(tt.filenames))
+			for j, filename := range tt.filenames {
+				ic, err := NewInputCsv("../test-files/" + filename)
+				if err != nil {
+					t.Fatal("Unexpected error", err)
+				}
+				inputCsvs[j] = ic
+			}
+			toc := new(testOutputCsv)
+			StackFiles(inputCsvs, toc, tt.groupName, tt.groups, tt.headerMode, tt.fill)
+			err := assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestStackGroupsWithArchive(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-stack-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	archiveFilename := filepath.Join(tmpDir, "bundle.zip")
+	writeTestArchive(t, archiveFilename, map[string]string{
+		"b.csv":        "ID,ABC\n1,One\n",
+		"nested/a.csv": "ID,ABC\n2,Two\n",
+	}, []string{"b.csv", "nested/a.csv"})
+	filenames := []string{archiveFilename, "../test-files/stack-2.csv"}
+
+	testCases := []struct {
+		groupsString string
+		useFilenames bool
+		rows         [][]string
+	}{
+		{"", true, [][]string{
+			{"ID", "ABC", "Group"},
+			{"1", "One", "b.csv"},
+			{"2", "Two", "nested/a.csv"},
+			{"6", "Six", "../test-files/stack-2.csv"},
+			{"7", "Seven", "../test-files/stack-2.csv"},
+		}},
+		{"x,y,z", false, [][]string{
+			{"ID", "ABC", "Group"},
+			{"1", "One", "x"},
+			{"2", "Two", "y"},
+			{"6", "Six", "z"},
+			{"7", "Seven", "z"},
+		}},
+		// One group for each argument, rather than each CSV.
+		{"x,y", false, nil},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			inputCsvs, err := GetInputCsvs(filenames, -1)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			groups, err := StackGroups(inputCsvs, tt.groupsString, tt.useFilenames)
+			if tt.rows == nil {
+				if err == nil {
+					t.Error("Expected an error for the number of groups")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			StackFiles(inputCsvs, toc, "Group", groups, STACK_HEADERS_MATCH, "")
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAlignHeaders(t *testing.T) {
+	headers := [][]string{
+		{"A", "B", "A"},
+		{"B", "C", "A"},
+		{"A", "A", "B", "D"},
+	}
+	testCases := []struct {
+		intersect     bool
+		alignedHeader []string
+		columnMaps    [][]int
+	}{
+		{false, []string{"A", "B", "A", "C", "D"}, [][]int{
+			{0, 1, 2, -1, -1},
+			{2, 0, -1, 1, -1},
+			{0, 2, 1, -1, 3},
+		}},
+		{true, []string{"A", "B"}, [][]int{
+			{0, 1},
+			{2, 0},
+			{0, 2},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			alignedHeader, columnMaps := AlignHeaders(headers, tt.intersect)
+			err := assertRowsEqual([][]string{tt.alignedHeader}, [][]string{alignedHeader})
+			if err != nil {
+				t.Error(err)
+			}
+			if fmt.Sprint(columnMaps) != fmt.Sprint(tt.columnMaps) {
+				t.Errorf("Expected column maps %v but got %v", tt.columnMaps, columnMaps)
+			}
+		})
+	}
+}
+
+func TestStackFilesNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	inputCsvs, err := GetInputCsvs([]string{"../test-files/stack-2.csv", "../test-files/stack-3.csv"}, -1)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	var buf bytes.Buffer
+	StackFiles(inputCsvs, NewOutputCsvFromWriter(&buf), "Group", []string{"two", "three"}, STACK_HEADERS_MATCH, "")
+	expected := "ID,ABC,two\n6,Six,two\n7,Seven,two\nID,ABC,three\n8,Eight,three\n9,Nine,three\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, buf.String())
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 type TailSubcommand struct {
 	numRowsStr string
+	follow     bool
 }
 
 func (sub *TailSubcommand) Name() string {
@@ -25,6 +26,8 @@ func (sub *TailSubcommand) Description() string {
 }
 func (sub *TailSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.StringVar(&sub.numRowsStr, "n", "10", "Number of rows to include")
+	fs.BoolVar(&sub.follow, "follow", false, "Keep writing rows as they are appended to the file")
+	fs.BoolVar(&sub.follow, "f", false, "Keep writing rows as they are appended to the file (shorthand)")
 }
 
 func (sub *TailSubcommand) Run(args []string) {
@@ -39,6 +42,12 @@ func (sub *TailSubcommand) RunTail(inputCsv *InputCsv, outputCsvWriter OutputCsv
 		fmt.Fprintln(os.Stderr, "Invalid argument to -n")
 		os.Exit(1)
 	}
+	if sub.follow {
+		err := inputCsv.PrepareToFollow(FOLLOW_POLL_INTERVAL)
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
 	if strings.HasPrefix(sub.numRowsStr, "+") {
 		numRowsStr := strings.TrimPrefix(sub.numRowsStr, "+")
 		numRows, err := strconv.Atoi(numRowsStr)
@@ -53,26 +62,190 @@ func (sub *TailSubcommand) RunTail(inputCsv *InputCsv, outputCsvWriter OutputCsv
 		}
 		TailFromBottom(inputCsv, outputCsvWriter, numRows)
 	}
+	if sub.follow {
+		FollowRows(inputCsv, outputCsvWriter)
+	}
+}
+
+// FollowRows writes rows as they are appended to the input, until the
+// input stops being followed.
+func FollowRows(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter) {
+	err := inputCsv.Follow(FOLLOW_POLL_INTERVAL)
+	if err != nil {
+		ExitWithError(err)
+	}
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		outputCsvWriter.Write(row)
+	}
 }
 
+// TAIL_BLOCK_SIZE is the number of bytes read at a time when
+// scanning backwards from the end of a file.
+const TAIL_BLOCK_SIZE = 64 * 1024
+
 func TailFromBottom(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, numRows int) {
-	// Read all rows.
-	rows, err := inputCsv.ReadAll()
+	// Read and write header.
+	header, err := inputCsv.Read()
 	if err != nil {
 		ExitWithError(err)
 	}
+	outputCsvWriter.Write(header)
+
+	if numRows == 0 {
+		return
+	}
+
+	// Regular files can be read backwards from the end, so only
+	// the last rows need to be parsed.
+	reader := inputCsv.Reader()
+	if inputCsv.IsRegularFile() && !reader.LazyQuotes && reader.Comment == 0 && reader.Quote == '"' && reader.Escape == 0 && inputCsv.skipFooter == 0 {
+		// When preparing to follow, a last line without a line
+		// terminator is still being written.
+		completeLinesOnly := inputCsv.follower != nil
+		offset, ok, err := FindOffsetOfLastRecords(inputCsv.File(), numRows, completeLinesOnly)
+		if err != nil {
+			ExitWithError(err)
+		}
+		// The offset must not fall within the header, or any lines
+		// skipped before it.
+		headerOffset, err := inputCsv.Offset()
+		if err != nil {
+			ExitWithError(err)
+		}
+		if ok && offset >= headerOffset {
+			// With --no-header, the first row has been read ahead
+			// of the header offset, so it must be kept in case the
+			// rows are read from the header again.
+			firstRow := inputCsv.firstRow
+			err = inputCsv.SeekToOffset(offset)
+			if err != nil {
+				ExitWithError(err)
+			}
+			// Parsing the rows from the offset checks that it is the
+			// start of the last rows. It can only fail to be if the
+			// quotes of the file are not balanced, in which case the
+			// rows are read from the start to report the error.
+			rows, err := readRowsToEnd(inputCsv)
+			if err == nil && len(rows) == numRows {
+				for _, row := range rows {
+					outputCsvWriter.Write(row)
+				}
+				return
+			}
+			err = inputCsv.SeekToOffset(headerOffset)
+			if err != nil {
+				ExitWithError(err)
+			}
+			inputCsv.firstRow = firstRow
+		}
+	}
 
-	// Write header.
-	outputCsvWriter.Write(rows[0])
+	// Otherwise keep the last rows in a ring buffer.
+	rows := make([][]string, numRows)
+	numRowsRead := 0
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		rows[numRowsRead%numRows] = row
+		numRowsRead++
+	}
 
 	// Write rows.
-	startRow := len(rows) - numRows
-	if startRow < 1 {
-		startRow = 1
+	startRow := numRowsRead - numRows
+	if startRow < 0 {
+		startRow = 0
+	}
+	for i := startRow; i < numRowsRead; i++ {
+		outputCsvWriter.Write(rows[i%numRows])
 	}
-	for i := startRow; i < len(rows); i++ {
-		outputCsvWriter.Write(rows[i])
+}
+
+// readRowsToEnd reads the remaining rows of an input.
+func readRowsToEnd(inputCsv *InputCsv) ([][]string, error) {
+	rows := make([][]string, 0)
+	for {
+		row, err := inputCsv.Read()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return rows, err
+		}
+		if inputCsv.Reader().ReuseRecord {
+			row = append([]string(nil), row...)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// FindOffsetOfLastRecords scans a file backwards from the end to find the
+// byte offset at which the last numRecords records start. It returns false if
+// the file has no more than numRecords records, since the offset could then
+// fall within the header.
+//
+// Every newline outside of quotes starts a record, except for the line
+// terminator at the end of the file. Blank lines are records too, as the
+// reader allows them. With completeLinesOnly, any line after the last line
+// terminator is ignored. As the quotes of a valid CSV are balanced, a newline
+// is outside of quotes if an even number of quotes follow it, doubled quotes
+// within a field counting twice.
+func FindOffsetOfLastRecords(file *os.File, numRecords int, completeLinesOnly bool) (offset int64, ok bool, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	pos := info.Size()
+	block := make([]byte, TAIL_BLOCK_SIZE)
+	numBoundaries := 0
+	inQuotes := false
+	atEnd := true
+	for pos > 0 {
+		blockSize := int64(len(block))
+		if pos < blockSize {
+			blockSize = pos
+		}
+		pos -= blockSize
+		_, err = file.ReadAt(block[:blockSize], pos)
+		if err != nil {
+			return
+		}
+		for i := int(blockSize) - 1; i >= 0; i-- {
+			c := block[i]
+			// The trailing newline ends the last record, rather than
+			// starting a new one.
+			if atEnd {
+				if c == '\n' {
+					atEnd = false
+					continue
+				} else if completeLinesOnly {
+					continue
+				}
+				atEnd = false
+			}
+			if c == '"' {
+				inQuotes = !inQuotes
+			} else if c == '\n' && !inQuotes {
+				numBoundaries++
+				if numBoundaries == numRecords {
+					return pos + int64(i) + 1, true, nil
+				}
+			}
+		}
 	}
+	return 0, false, nil
 }
 
 func TailFromTop(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, numRows int) {
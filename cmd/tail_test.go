@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestRunTail(t *testing.T) {
@@ -48,3 +53,300 @@ func TestRunTail(t *testing.T) {
 		})
 	}
 }
+
+func TestTailFromBottomMultiline(t *testing.T) {
+	testCases := []struct {
+		numRows    int
+		lazyQuotes bool
+		rows       [][]string
+	}{
+		{2, false, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "say \"hi\"\nthere"},
+			[]string{"3", "plain"},
+		}},
+		{3, false, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"1", "line one\nline two"},
+			[]string{"2", "say \"hi\"\nthere"},
+			[]string{"3", "plain"},
+		}},
+		{5, false, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"1", "line one\nline two"},
+			[]string{"2", "say \"hi\"\nthere"},
+			[]string{"3", "plain"},
+		}},
+		// Lazy quotes fall back to reading the whole file.
+		{2, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "say \"hi\"\nthere"},
+			[]string{"3", "plain"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsv("../test-files/multiline.csv")
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			ic.SetLazyQuotes(tt.lazyQuotes)
+			toc := new(testOutputCsv)
+			TailFromBottom(ic, toc, tt.numRows)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestTailFromBottomBlankAndQuotedLines(t *testing.T) {
+	testCases := []struct {
+		contents string
+		numRows  int
+		ok       bool
+		rows     [][]string
+	}{
+		// Blank lines are records, but the final line terminator
+		// doesn't start one.
+		{"ID,Text\n1,a\n2,b\n\n", 2, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "b"},
+			[]string{""},
+		}},
+		{"ID,Text\r\n1,a\r\n\r\n2,b\r\n", 2, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{""},
+			[]string{"2", "b"},
+		}},
+		{"ID,Text\n1,a\n2,b", 1, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "b"},
+		}},
+		// Newlines within quoted fields don't start records.
+		{"ID,Text\n1,a\n2,\"b\n3,c\"\n", 1, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "b\n3,c"},
+		}},
+		{"ID,Text\n1,\"say \"\"hi\n\"\"\"\n2,\"x\n\"\"\n3,\"\"\"\n", 1, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "x\n\"\n3,\""},
+		}},
+		{"ID,Text\n1,\"a\n\"\"b\"\n2,\"\"\n", 2, true, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"1", "a\n\"b"},
+			[]string{"2", ""},
+		}},
+	}
+	tmpDir, err := ioutil.TempDir("", "gocsv-tail-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			filename := filepath.Join(tmpDir, fmt.Sprintf("tail-%d.csv", i))
+			err := ioutil.WriteFile(filename, []byte(tt.contents), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ic, err := NewInputCsv(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ic.Close()
+			ic.SetFieldsPerRecord(-1)
+			_, ok, err := FindOffsetOfLastRecords(ic.File(), tt.numRows, false)
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			if ok != tt.ok {
+				t.Errorf("Expected ok to be %v but got %v", tt.ok, ok)
+			}
+			toc := new(testOutputCsv)
+			TailFromBottom(ic, toc, tt.numRows)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestInputCsvFollow(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gocsv-follow-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filename := filepath.Join(tmpDir, "follow.csv")
+	err = ioutil.WriteFile(filename, []byte("ID,Text\n1,first row\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ic, err := NewInputCsv(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ic.Close()
+	header, err := ic.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ic.Follow(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectRow := func(expected []string) {
+		t.Helper()
+		row, err := ic.Read()
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		err = assertRowsEqual([][]string{expected}, [][]string{row})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	appendToFile := func(contents string) {
+		t.Helper()
+		file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		_, err = file.WriteString(contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = assertRowsEqual([][]string{{"ID", "Text"}}, [][]string{header})
+	if err != nil {
+		t.Error(err)
+	}
+	expectRow([]string{"1", "first row"})
+
+	// Rows appended after reaching the end of the file, including one
+	// written in pieces.
+	appendToFile("2,second row\n3,\"multi")
+	expectRow([]string{"2", "second row"})
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		appendToFile("line\nrow\"\n")
+	}()
+	expectRow([]string{"3", "multiline\nrow"})
+
+	// Truncation restarts from the beginning, skipping the header.
+	err = ioutil.WriteFile(filename, []byte("ID,Text\n4,a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectRow([]string{"4", "a"})
+
+	// Rotation switches to the new file, skipping the header.
+	err = os.Rename(filename, filename+".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filename, []byte("\"ID\nNumber\",Text\n5,rotated\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectRow([]string{"5", "rotated"})
+
+	// Reading stops once the input is no longer followed.
+	ic.follower.Close()
+	_, err = ic.Read()
+	if err != io.EOF {
+		t.Error("Expected io.EOF but got", err)
+	}
+}
+
+func TestTailFollowPartialLine(t *testing.T) {
+	testCases := []struct {
+		numRows int
+		rows    [][]string
+	}{
+		{1, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"2", "b"},
+		}},
+		{10, [][]string{
+			[]string{"ID", "Text"},
+			[]string{"1", "a"},
+			[]string{"2", "b"},
+		}},
+	}
+	tmpDir, err := ioutil.TempDir("", "gocsv-follow-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			filename := filepath.Join(tmpDir, fmt.Sprintf("follow-%d.csv", i))
+			err := ioutil.WriteFile(filename, []byte("ID,Text\n1,a\n2,b\n3,par"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ic, err := NewInputCsv(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ic.Close()
+			err = ic.PrepareToFollow(10 * time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The last line is left until it is complete.
+			toc := new(testOutputCsv)
+			TailFromBottom(ic, toc, tt.numRows)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+			err = ic.Follow(10 * time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			go func() {
+				time.Sleep(30 * time.Millisecond)
+				file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					return
+				}
+				defer file.Close()
+				file.WriteString("tial\n")
+			}()
+			row, err := ic.Read()
+			if err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			err = assertRowsEqual([][]string{{"3", "partial"}}, [][]string{row})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFollowWithSkipFooter(t *testing.T) {
+	SKIP_FOOTER = 1
+	defer func() {
+		SKIP_FOOTER = 0
+	}()
+	ic, err := NewInputCsv("../test-files/simple-sort.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ic.Close()
+	err = ic.Follow(10 * time.Millisecond)
+	if err == nil {
+		t.Error("Expected an error following with --skip-footer")
+	}
+}
@@ -1,17 +1,24 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"io"
+	"sort"
 	"strconv"
 
 	"github.com/alphagov/router/trie"
 )
 
 type UniqueSubcommand struct {
-	columnsString string
-	sorted        bool
-	count         bool
+	columnsString  string
+	sorted         bool
+	count          bool
+	hash           bool
+	partitions     int
+	duplicatesOnly bool
+	keep           string
 }
 
 func (sub *UniqueSubcommand) Name() string {
@@ -28,6 +35,10 @@ func (sub *UniqueSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.StringVar(&sub.columnsString, "c", "", "Columns to use for comparison (shorthand)")
 	fs.BoolVar(&sub.sorted, "sorted", false, "Whether input CSV is already sorted")
 	fs.BoolVar(&sub.count, "count", false, "Whether to append a Count column")
+	fs.BoolVar(&sub.hash, "hash", false, "Compare rows by 128-bit fingerprints to reduce memory")
+	fs.IntVar(&sub.partitions, "partitions", 0, "Number of temporary files to partition rows into")
+	fs.BoolVar(&sub.duplicatesOnly, "duplicates-only", false, "Output only the rows that have duplicates")
+	fs.StringVar(&sub.keep, "keep", "first", "Which occurrence of a unique row to keep: first or last")
 }
 
 func (sub *UniqueSubcommand) Run(args []string) {
@@ -44,12 +55,55 @@ func (sub *UniqueSubcommand) RunUnique(inputCsv *InputCsv, outputCsvWriter Outpu
 		columns = GetArrayFromCsvString(sub.columnsString)
 	}
 
-	if sub.sorted {
+	if sub.keep != "" && sub.keep != "first" && sub.keep != "last" {
+		ExitWithError(fmt.Errorf("Invalid argument for --keep: %s", sub.keep))
+	}
+	keepLast := sub.keep == "last"
+
+	if sub.sorted && sub.hash {
+		ExitWithError(errors.New("Cannot specify both --sorted and --hash"))
+	}
+	if sub.sorted && sub.partitions != 0 {
+		ExitWithError(errors.New("Cannot specify both --sorted and --partitions"))
+	}
+	if sub.partitions < 0 || sub.partitions > MAX_UNIQUE_PARTITIONS {
+		ExitWithError(fmt.Errorf("Invalid argument for --partitions: must be between 1 and %d", MAX_UNIQUE_PARTITIONS))
+	}
+
+	if sub.duplicatesOnly {
+		if keepLast {
+			ExitWithError(errors.New("Cannot specify --keep with --duplicates-only"))
+		}
+		if sub.sorted {
+			ReportDuplicatesSorted(inputCsv, outputCsvWriter, columns)
+		} else if sub.partitions > 0 {
+			ReportDuplicatesPartitioned(inputCsv, outputCsvWriter, columns, sub.partitions)
+		} else if sub.hash {
+			ReportDuplicatesPartitioned(inputCsv, outputCsvWriter, columns, DEFAULT_UNIQUE_PARTITIONS)
+		} else {
+			ReportDuplicatesUnsorted(inputCsv, outputCsvWriter, columns)
+		}
+		return
+	}
+
+	if sub.sorted && keepLast {
+		UniqueifySortedKeepLast(inputCsv, outputCsvWriter, columns, sub.count)
+	} else if sub.sorted {
 		if sub.count {
 			UniqueifySortedWithCount(inputCsv, outputCsvWriter, columns)
 		} else {
 			UniqueifySorted(inputCsv, outputCsvWriter, columns)
 		}
+	} else if sub.partitions > 0 {
+		UniqueifyUnsortedPartitioned(inputCsv, outputCsvWriter, columns, sub.count, keepLast, sub.partitions)
+	} else if sub.hash && sub.count {
+		// Counting needs the unique rows, which are only held in memory
+		// one partition at a time.
+		UniqueifyUnsortedPartitioned(inputCsv, outputCsvWriter, columns, sub.count, keepLast, DEFAULT_UNIQUE_PARTITIONS)
+	} else if keepLast {
+		UniqueifyUnsortedKeepLast(inputCsv, outputCsvWriter, columns, sub.count, sub.hash)
+	} else if sub.hash {
+		UniqueifyUnsortedHashed(inputCsv, outputCsvWriter, columns)
 	} else {
 		if sub.count {
 			UniqueifyUnsortedWithCount(inputCsv, outputCsvWriter, columns)
@@ -235,3 +289,223 @@ func UniqueifyUnsortedWithCount(inputCsv *InputCsv, outputCsvWriter OutputCsvWri
 		}
 	}
 }
+
+// UniqueifySortedKeepLast writes the last row of each run of matching rows,
+// optionally appending the number of rows in the run.
+func UniqueifySortedKeepLast(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string, withCount bool) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	writeRow := func(row []string, count int) {
+		if withCount {
+			row = append(row, strconv.Itoa(count))
+		}
+		outputCsvWriter.Write(row)
+	}
+
+	// Write header.
+	if withCount {
+		outputCsvWriter.Write(append(header, "Count"))
+	} else {
+		outputCsvWriter.Write(header)
+	}
+
+	var lastRow []string
+	numInRun := 0
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		if lastRow != nil && !rowMatchesOnIndices(row, lastRow, columnIndices) {
+			writeRow(lastRow, numInRun)
+			numInRun = 0
+		}
+		lastRow = row
+		numInRun++
+	}
+	if lastRow != nil {
+		writeRow(lastRow, numInRun)
+	}
+}
+
+// UniqueifyUnsortedKeepLast writes the last occurrence of each unique row,
+// in the order of those last occurrences, optionally appending the number of
+// times the row occurred. Only the unique rows are held in memory. If hash is
+// true, rows are identified by their fingerprints rather than their values.
+func UniqueifyUnsortedKeepLast(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string, withCount, hash bool) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	type lastOccurrence struct {
+		row   []string
+		line  int
+		count int
+	}
+	occurrences := make(map[string]*lastOccurrence)
+
+	line := 0
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		line++
+		var key string
+		if hash {
+			fp := GetRowFingerprint(row, columnIndices)
+			key = string(fp[:])
+		} else {
+			key = GetRowKey(row, columnIndices)
+		}
+		occurrence, ok := occurrences[key]
+		if ok {
+			occurrence.row = row
+			occurrence.line = line
+			occurrence.count++
+		} else {
+			occurrences[key] = &lastOccurrence{row: row, line: line, count: 1}
+		}
+	}
+
+	sortedOccurrences := make([]*lastOccurrence, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		sortedOccurrences = append(sortedOccurrences, occurrence)
+	}
+	sort.Slice(sortedOccurrences, func(i, j int) bool {
+		return sortedOccurrences[i].line < sortedOccurrences[j].line
+	})
+
+	// Write header.
+	if withCount {
+		outputCsvWriter.Write(append(header, "Count"))
+	} else {
+		outputCsvWriter.Write(header)
+	}
+
+	for _, occurrence := range sortedOccurrences {
+		row := occurrence.row
+		if withCount {
+			row = append(row, strconv.Itoa(occurrence.count))
+		}
+		outputCsvWriter.Write(row)
+	}
+}
+
+// duplicateColumnNames are appended to the header when reporting duplicates.
+// The "Row" column is the number of the row in the input, counting the
+// first row after the header as row 1. It is not a line number, since a
+// row may span several lines.
+var duplicateColumnNames = []string{"Count", "Group", "Row"}
+
+// ReportDuplicatesSorted writes every row of each run of at least two
+// matching rows, appending the number of rows in the run, an incrementing
+// group ID for the run and the number of the row in the input.
+func ReportDuplicatesSorted(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	// Write header.
+	outputCsvWriter.Write(append(header, duplicateColumnNames...))
+
+	groupId := 0
+	run := make([][]string, 0)
+	runStartRow := 0
+	writeRun := func() {
+		if len(run) < 2 {
+			return
+		}
+		groupId++
+		count := strconv.Itoa(len(run))
+		for i, row := range run {
+			outputCsvWriter.Write(append(row, count, strconv.Itoa(groupId), strconv.Itoa(runStartRow+i)))
+		}
+	}
+
+	rowNumber := 0
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		rowNumber++
+		if len(run) > 0 && !rowMatchesOnIndices(row, run[0], columnIndices) {
+			writeRun()
+			run = run[:0]
+		}
+		if len(run) == 0 {
+			runStartRow = rowNumber
+		}
+		run = append(run, row)
+	}
+	writeRun()
+}
+
+// ReportDuplicatesUnsorted writes every row whose values in the columns occur
+// more than once, in input order, appending the number of occurrences, a group
+// ID numbered by first occurrence and the number of the row in the input.
+// The entire CSV is held in memory, so ReportDuplicatesPartitioned should be
+// used for large inputs.
+func ReportDuplicatesUnsorted(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string) {
+	imc := NewInMemoryCsvFromInputCsv(inputCsv)
+
+	columnIndices := GetIndicesForColumnsOrPanic(imc.header, columns)
+
+	type duplicateGroup struct {
+		count   int
+		groupId int
+	}
+	groups := make(map[string]*duplicateGroup)
+	rowKeys := make([]string, len(imc.rows))
+
+	for rowIndex, row := range imc.rows {
+		key := GetRowKey(row, columnIndices)
+		rowKeys[rowIndex] = key
+		group, ok := groups[key]
+		if ok {
+			group.count++
+		} else {
+			groups[key] = &duplicateGroup{count: 1}
+		}
+	}
+
+	// Write header.
+	outputCsvWriter.Write(append(imc.header, duplicateColumnNames...))
+
+	numGroups := 0
+	for rowIndex, row := range imc.rows {
+		group := groups[rowKeys[rowIndex]]
+		if group.count < 2 {
+			continue
+		}
+		if group.groupId == 0 {
+			numGroups++
+			group.groupId = numGroups
+		}
+		outputCsvWriter.Write(append(row, strconv.Itoa(group.count), strconv.Itoa(group.groupId), strconv.Itoa(rowIndex+1)))
+	}
+}
@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DEFAULT_UNIQUE_PARTITIONS is the number of partitions used to count
+// unique rows with --hash when --partitions is not specified.
+const DEFAULT_UNIQUE_PARTITIONS = 16
+
+// MAX_UNIQUE_PARTITIONS is the most partitions allowed with --partitions,
+// since a temporary file is kept open for each of them.
+const MAX_UNIQUE_PARTITIONS = 256
+
+// RowFingerprint is a 128-bit hash of the values of a row in certain columns.
+type RowFingerprint [16]byte
+
+// GetRowFingerprint computes the fingerprint of a row in the specified columns.
+func GetRowFingerprint(row []string, columnIndices []int) (fp RowFingerprint) {
+	h := fnv.New128a()
+	io.WriteString(h, GetRowKey(row, columnIndices))
+	copy(fp[:], h.Sum(nil))
+	return
+}
+
+// Partition returns the partition of numPartitions that the fingerprint
+// falls in, spreading fingerprints evenly across any number of partitions.
+// The bits of an FNV hash are not evenly mixed, so both halves of the
+// fingerprint are combined and mixed with the MurmurHash3 finalizer.
+func (fp RowFingerprint) Partition(numPartitions int) int {
+	x := binary.BigEndian.Uint64(fp[:8]) ^ binary.BigEndian.Uint64(fp[8:])
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return int(x % uint64(numPartitions))
+}
+
+// UniqueifyUnsortedHashed writes the first occurrence of each unique row,
+// keeping only the fingerprints of the rows seen so far in memory.
+func UniqueifyUnsortedHashed(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	// Write header.
+	outputCsvWriter.Write(header)
+
+	seenFingerprints := make(map[RowFingerprint]struct{})
+
+	// Write unique rows in order.
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				ExitWithError(err)
+			}
+		}
+		fp := GetRowFingerprint(row, columnIndices)
+		if _, ok := seenFingerprints[fp]; !ok {
+			seenFingerprints[fp] = struct{}{}
+			outputCsvWriter.Write(row)
+		}
+	}
+}
+
+// UniqueifyUnsortedPartitioned finds unique rows using a bounded amount of
+// memory by spilling the rows to numPartitions temporary files, partitioned
+// by fingerprint. Since equal rows always land in the same partition, each
+// partition can be deduplicated on its own, only holding that partition's
+// fingerprints in memory. The first occurrences from each partition are then
+// merged back together by their row number to preserve the input order.
+// If keepLast is true, the last occurrence of each unique row is kept instead.
+func UniqueifyUnsortedPartitioned(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string, withCount, keepLast bool, numPartitions int) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	tmpDir, err := ioutil.TempDir("", "gocsv-unique-")
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	partitionFilenames, err := writeUniquePartitions(inputCsv, columnIndices, tmpDir, numPartitions)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	readers := make([]RowReader, numPartitions)
+	for i, partitionFilename := range partitionFilenames {
+		uniqueFilename := filepath.Join(tmpDir, "unique-"+strconv.Itoa(i))
+		err = uniqueifyPartition(partitionFilename, uniqueFilename, keepLast)
+		if err != nil {
+			ExitWithError(err)
+		}
+		file, err := os.Open(uniqueFilename)
+		if err != nil {
+			ExitWithError(err)
+		}
+		defer file.Close()
+		readers[i] = newRunFileReader(file)
+	}
+
+	// Write header.
+	if withCount {
+		outputCsvWriter.Write(append(header, "Count"))
+	} else {
+		outputCsvWriter.Write(header)
+	}
+
+	// Merge the partitions on the row number.
+	sortColumns := []SortColumn{{index: 0, columnType: INT_TYPE}}
+	pw := &partitionRowWriter{outputCsvWriter: outputCsvWriter, withCount: withCount}
+	err = MergeSortedRows(readers, sortColumns, pw)
+	if err != nil {
+		ExitWithError(err)
+	}
+}
+
+// writeUniquePartitions writes each row, prefixed by its row number and
+// fingerprint, to the partition determined by its fingerprint.
+func writeUniquePartitions(inputCsv *InputCsv, columnIndices []int, tmpDir string, numPartitions int) ([]string, error) {
+	filenames := make([]string, numPartitions)
+	writers := make([]*runFileWriter, numPartitions)
+	for i := range filenames {
+		filenames[i] = filepath.Join(tmpDir, "partition-"+strconv.Itoa(i))
+		file, err := os.Create(filenames[i])
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		writers[i] = newRunFileWriter(file)
+	}
+
+	rowNumber := 0
+	for {
+		row, err := inputCsv.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				return nil, err
+			}
+		}
+		rowNumber++
+		fp := GetRowFingerprint(row, columnIndices)
+		partition := fp.Partition(numPartitions)
+		record := make([]string, len(row)+2)
+		record[0] = strconv.Itoa(rowNumber)
+		record[1] = hex.EncodeToString(fp[:])
+		copy(record[2:], row)
+		err = writers[partition].Write(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, writer := range writers {
+		err := writer.Flush()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filenames, nil
+}
+
+// uniqueifyPartition makes two passes over a partition file: the first counts
+// the occurrences of each fingerprint and the second writes the first (or last)
+// occurrence of each, as the row number, count and original row.
+func uniqueifyPartition(partitionFilename, uniqueFilename string, keepLast bool) error {
+	type fingerprintCount struct {
+		keptRow string
+		count   int
+	}
+	counts := make(map[string]*fingerprintCount)
+	err := forEachPartitionRecord(partitionFilename, func(record []string) error {
+		fc, ok := counts[record[1]]
+		if ok {
+			fc.count++
+			if keepLast {
+				fc.keptRow = record[0]
+			}
+		} else {
+			counts[record[1]] = &fingerprintCount{keptRow: record[0], count: 1}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(uniqueFilename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := newRunFileWriter(file)
+	err = forEachPartitionRecord(partitionFilename, func(record []string) error {
+		fc := counts[record[1]]
+		if fc.keptRow != record[0] {
+			return nil
+		}
+		record[1] = strconv.Itoa(fc.count)
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func forEachPartitionRecord(filename string, f func(record []string) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := newRunFileReader(file)
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			} else {
+				return err
+			}
+		}
+		err = f(record)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReportDuplicatesPartitioned writes the same report as
+// ReportDuplicatesUnsorted using a bounded amount of memory. The rows are
+// spilled to numPartitions temporary files partitioned by fingerprint, the
+// duplicates are found one partition at a time, and then they are merged back
+// together by their row number. Only the groups of duplicates that have not
+// been written in full yet are held in memory while merging.
+func ReportDuplicatesPartitioned(inputCsv *InputCsv, outputCsvWriter OutputCsvWriter, columns []string, numPartitions int) {
+	header, err := inputCsv.Read()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	columnIndices := GetIndicesForColumnsOrPanic(header, columns)
+
+	tmpDir, err := ioutil.TempDir("", "gocsv-unique-")
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	partitionFilenames, err := writeUniquePartitions(inputCsv, columnIndices, tmpDir, numPartitions)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	readers := make([]RowReader, numPartitions)
+	for i, partitionFilename := range partitionFilenames {
+		duplicatesFilename := filepath.Join(tmpDir, "duplicates-"+strconv.Itoa(i))
+		err = reportPartitionDuplicates(partitionFilename, duplicatesFilename)
+		if err != nil {
+			ExitWithError(err)
+		}
+		file, err := os.Open(duplicatesFilename)
+		if err != nil {
+			ExitWithError(err)
+		}
+		defer file.Close()
+		readers[i] = newRunFileReader(file)
+	}
+
+	// Write header.
+	outputCsvWriter.Write(append(header, duplicateColumnNames...))
+
+	// Merge the partitions on the row number.
+	sortColumns := []SortColumn{{index: 0, columnType: INT_TYPE}}
+	dw := &duplicateRowWriter{outputCsvWriter: outputCsvWriter, openGroups: make(map[string]*openDuplicateGroup)}
+	err = MergeSortedRows(readers, sortColumns, dw)
+	if err != nil {
+		ExitWithError(err)
+	}
+}
+
+// reportPartitionDuplicates makes two passes over a partition file: the first
+// counts the occurrences of each fingerprint and the second writes every row
+// whose fingerprint occurs more than once, as the row number, count, row
+// number of the first occurrence and original row.
+func reportPartitionDuplicates(partitionFilename, duplicatesFilename string) error {
+	type fingerprintCount struct {
+		firstRow string
+		count    int
+	}
+	counts := make(map[string]*fingerprintCount)
+	err := forEachPartitionRecord(partitionFilename, func(record []string) error {
+		fc, ok := counts[record[1]]
+		if ok {
+			fc.count++
+		} else {
+			counts[record[1]] = &fingerprintCount{firstRow: record[0], count: 1}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(duplicatesFilename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := newRunFileWriter(file)
+	err = forEachPartitionRecord(partitionFilename, func(record []string) error {
+		fc := counts[record[1]]
+		if fc.count < 2 {
+			return nil
+		}
+		duplicate := make([]string, 0, len(record)+1)
+		duplicate = append(duplicate, record[0], strconv.Itoa(fc.count), fc.firstRow)
+		duplicate = append(duplicate, record[2:]...)
+		return writer.Write(duplicate)
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// duplicateRowWriter numbers the groups of duplicates merged from the
+// partitions by their first occurrence, and writes each row along with its
+// count, group ID and row number.
+type duplicateRowWriter struct {
+	outputCsvWriter OutputCsvWriter
+	numGroups       int
+	// openGroups holds the groups with rows left to be written, keyed
+	// by the row number of their first occurrence.
+	openGroups map[string]*openDuplicateGroup
+}
+
+type openDuplicateGroup struct {
+	groupId   int
+	remaining int
+}
+
+func (dw *duplicateRowWriter) Write(record []string) error {
+	group, ok := dw.openGroups[record[2]]
+	if !ok {
+		count, err := strconv.Atoi(record[1])
+		if err != nil {
+			return err
+		}
+		dw.numGroups++
+		group = &openDuplicateGroup{groupId: dw.numGroups, remaining: count}
+		dw.openGroups[record[2]] = group
+	}
+	group.remaining--
+	if group.remaining == 0 {
+		delete(dw.openGroups, record[2])
+	}
+	row := make([]string, 0, len(record))
+	row = append(row, record[3:]...)
+	row = append(row, record[1], strconv.Itoa(group.groupId), record[0])
+	return dw.outputCsvWriter.Write(row)
+}
+
+// partitionRowWriter strips the line number and count from the
+// deduplicated partition records, appending the count if requested.
+type partitionRowWriter struct {
+	outputCsvWriter OutputCsvWriter
+	withCount       bool
+}
+
+func (pw *partitionRowWriter) Write(record []string) error {
+	row := record[2:]
+	if pw.withCount {
+		row = append(row, record[1])
+	}
+	return pw.outputCsvWriter.Write(row)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 )
@@ -66,3 +67,194 @@ func TestRunUnique(t *testing.T) {
 		})
 	}
 }
+
+func TestRunUniqueHashed(t *testing.T) {
+	testCases := []struct {
+		columnsString string
+		count         bool
+		hash          bool
+		partitions    int
+		rows          [][]string
+	}{
+		{"Number", false, true, 0, [][]string{
+			[]string{"Number", "String"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"-1", "Minus One"},
+		}},
+		{"Number", true, true, 0, [][]string{
+			[]string{"Number", "String", "Count"},
+			[]string{"1", "One", "1"},
+			[]string{"2", "Two", "2"},
+			[]string{"-1", "Minus One", "1"},
+		}},
+		{"Number,String", false, true, 0, [][]string{
+			[]string{"Number", "String"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"-1", "Minus One"},
+			[]string{"2", "Another Two"},
+		}},
+		{"Number", false, false, 3, [][]string{
+			[]string{"Number", "String"},
+			[]string{"1", "One"},
+			[]string{"2", "Two"},
+			[]string{"-1", "Minus One"},
+		}},
+		{"Number", true, false, 3, [][]string{
+			[]string{"Number", "String", "Count"},
+			[]string{"1", "One", "1"},
+			[]string{"2", "Two", "2"},
+			[]string{"-1", "Minus One", "1"},
+		}},
+		{"", true, false, 1, [][]string{
+			[]string{"Number", "String", "Count"},
+			[]string{"1", "One", "1"},
+			[]string{"2", "Two", "1"},
+			[]string{"-1", "Minus One", "1"},
+			[]string{"2", "Another Two", "1"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsv("../test-files/simple-sort.csv")
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(UniqueSubcommand)
+			sub.columnsString = tt.columnsString
+			sub.count = tt.count
+			sub.hash = tt.hash
+			sub.partitions = tt.partitions
+			sub.RunUnique(ic, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRowFingerprintPartition(t *testing.T) {
+	// More partitions than there are values of a single byte.
+	numPartitions := 300
+	counts := make([]int, numPartitions)
+	for i := 0; i < 30000; i++ {
+		fp := GetRowFingerprint([]string{fmt.Sprintf("row %d", i)}, []int{0})
+		counts[fp.Partition(numPartitions)]++
+	}
+	for partition, count := range counts {
+		if count < 50 || count > 150 {
+			t.Errorf("Expected about 100 rows in partition %d but got %d", partition, count)
+		}
+	}
+}
+
+func TestRunUniqueKeepAndDuplicates(t *testing.T) {
+	testCases := []struct {
+		filename       string
+		columnsString  string
+		sorted         bool
+		count          bool
+		hash           bool
+		partitions     int
+		duplicatesOnly bool
+		keep           string
+		rows           [][]string
+	}{
+		{"simple-sort.csv", "Number", false, false, false, 0, false, "last", [][]string{
+			[]string{"Number", "String"},
+			[]string{"1", "One"},
+			[]string{"-1", "Minus One"},
+			[]string{"2", "Another Two"},
+		}},
+		{"simple-sort.csv", "Number", false, true, true, 0, false, "last", [][]string{
+			[]string{"Number", "String", "Count"},
+			[]string{"1", "One", "1"},
+			[]string{"-1", "Minus One", "1"},
+			[]string{"2", "Another Two", "2"},
+		}},
+		{"simple-sort.csv", "Number", false, true, false, 2, false, "last", [][]string{
+			[]string{"Number", "String", "Count"},
+			[]string{"1", "One", "1"},
+			[]string{"-1", "Minus One", "1"},
+			[]string{"2", "Another Two", "2"},
+		}},
+		{"sort-table.csv", "LID", true, true, false, 0, false, "last", [][]string{
+			[]string{"LID", "ABC", "Count"},
+			[]string{"1", "One-1", "1"},
+			[]string{"2", "Two-2", "2"},
+			[]string{"3", "Three-1", "1"},
+			[]string{"4", "Four-1", "1"},
+			[]string{"5", "Five-1", "1"},
+			[]string{"10", "Ten-1", "1"},
+		}},
+		{"simple-sort.csv", "Number", false, false, false, 0, true, "first", [][]string{
+			[]string{"Number", "String", "Count", "Group", "Row"},
+			[]string{"2", "Two", "2", "1", "2"},
+			[]string{"2", "Another Two", "2", "1", "4"},
+		}},
+		{"simple-sort.csv", "Number", false, false, true, 0, true, "first", [][]string{
+			[]string{"Number", "String", "Count", "Group", "Row"},
+			[]string{"2", "Two", "2", "1", "2"},
+			[]string{"2", "Another Two", "2", "1", "4"},
+		}},
+		{"simple-sort.csv", "", false, false, false, 0, true, "first", [][]string{
+			[]string{"Number", "String", "Count", "Group", "Row"},
+		}},
+		{"sort-table.csv", "LID", true, false, false, 0, true, "first", [][]string{
+			[]string{"LID", "ABC", "Count", "Group", "Row"},
+			[]string{"2", "Two-1", "2", "1", "2"},
+			[]string{"2", "Two-2", "2", "1", "3"},
+		}},
+		{"sort-table.csv", "LID", false, false, false, 3, true, "first", [][]string{
+			[]string{"LID", "ABC", "Count", "Group", "Row"},
+			[]string{"2", "Two-1", "2", "1", "2"},
+			[]string{"2", "Two-2", "2", "1", "3"},
+		}},
+	}
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i), func(t *testing.T) {
+			ic, err := NewInputCsv("../test-files/" + tt.filename)
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			toc := new(testOutputCsv)
+			sub := new(UniqueSubcommand)
+			sub.columnsString = tt.columnsString
+			sub.sorted = tt.sorted
+			sub.count = tt.count
+			sub.hash = tt.hash
+			sub.partitions = tt.partitions
+			sub.duplicatesOnly = tt.duplicatesOnly
+			sub.keep = tt.keep
+			sub.RunUnique(ic, toc)
+			err = assertRowsEqual(tt.rows, toc.rows)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRunUniqueNoHeader(t *testing.T) {
+	NO_HEADER = true
+	defer func() { NO_HEADER = false }()
+	for _, hash := range []bool{false, true} {
+		ic, err := NewInputCsv("../test-files/simple-sort.csv")
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		var buf bytes.Buffer
+		sub := new(UniqueSubcommand)
+		sub.columnsString = "1"
+		sub.count = true
+		sub.hash = hash
+		sub.RunUnique(ic, NewOutputCsvFromWriter(&buf))
+		expected := "Number,String,1\n1,One,1\n2,Two,2\n-1,Minus One,1\n"
+		if buf.String() != expected {
+			t.Errorf("Expected %q but got %q", expected, buf.String())
+		}
+	}
+}
@@ -161,13 +161,9 @@ type RowSorter struct {
 	by   func(r1, r2 *[]string) bool
 }
 
-func (by SortRowsBy) Sort(rows [][]string, reverse bool) {
+func (by SortRowsBy) Stable(rows [][]string) {
 	rs := &RowSorter{rows: rows, by: by}
-	if reverse {
-		sort.Sort(sort.Reverse(rs))
-	} else {
-		sort.Sort(rs)
-	}
+	sort.Stable(rs)
 }
 func (rs *RowSorter) Len() int {
 	return len(rs.rows)
@@ -179,6 +175,34 @@ func (rs *RowSorter) Less(i, j int) bool {
 	return rs.by(&rs.rows[i], &rs.rows[j])
 }
 
+// ParseByteSize parses a human readable number of bytes such as "512",
+// "100KB", "64MB" or "2G". Units are powers of 1024 and are case insensitive.
+func ParseByteSize(sizeStr string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(sizeStr))
+	s = strings.TrimSuffix(s, "B")
+	multiplier := int64(1)
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'K':
+			multiplier = 1024
+		case 'M':
+			multiplier = 1024 * 1024
+		case 'G':
+			multiplier = 1024 * 1024 * 1024
+		case 'T':
+			multiplier = 1024 * 1024 * 1024 * 1024
+		}
+		if multiplier > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("Invalid size \"%s\"", sizeStr)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
 func GetBaseFilenameWithoutExtension(filename string) string {
 	baseFilename := path.Base(filename)
 	extension := path.Ext(baseFilename)
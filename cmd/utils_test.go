@@ -57,3 +57,33 @@ func TestGetBaseFilenameWithoutExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestParseByteSize(t *testing.T) {
+	testCases := []struct {
+		sizeStr string
+		size    int64
+	}{
+		{"512", 512},
+		{"1KB", 1024},
+		{"1k", 1024},
+		{"100MB", 100 * 1024 * 1024},
+		{"1.5G", 1536 * 1024 * 1024},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.sizeStr, func(t *testing.T) {
+			size, err := ParseByteSize(tt.sizeStr)
+			if err != nil {
+				t.Error("Unexpected error", err)
+			}
+			if size != tt.size {
+				t.Errorf("Expected %d but got %d", tt.size, size)
+			}
+		})
+	}
+	for _, sizeStr := range []string{"", "MB", "-1", "abc"} {
+		_, err := ParseByteSize(sizeStr)
+		if err == nil {
+			t.Errorf("Expected error for %q", sizeStr)
+		}
+	}
+}
@@ -11,9 +11,10 @@ import (
 )
 
 type XlsxSubcommand struct {
-	listSheets bool
-	dirname    string
-	sheet      string
+	listSheets  bool
+	dirname     string
+	sheet       string
+	compression string
 }
 
 func (sub *XlsxSubcommand) Name() string {
@@ -29,6 +30,7 @@ func (sub *XlsxSubcommand) SetFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&sub.listSheets, "list-sheets", false, "List sheets in file")
 	fs.StringVar(&sub.dirname, "dirname", "", "Name of folder to output sheets to")
 	fs.StringVar(&sub.sheet, "sheet", "", "Name of sheet to convert")
+	fs.StringVar(&sub.compression, "output-compression", "", "Compression format of CSVs written to the folder: gzip or zlib")
 }
 
 func (sub *XlsxSubcommand) Run(args []string) {
@@ -48,14 +50,18 @@ func (sub *XlsxSubcommand) Run(args []string) {
 				fileParts := strings.Split(filename, ".")
 				sub.dirname = strings.Join(fileParts[:len(fileParts)-1], ".")
 			}
-			ConvertXlsxFull(filename, sub.dirname)
+			compression, err := ParseOutputCompression(sub.compression)
+			if err != nil {
+				ExitWithError(err)
+			}
+			ConvertXlsxFull(filename, sub.dirname, compression)
 		} else {
 			ConvertXlsxSheet(filename, sub.sheet)
 		}
 	}
 }
 
-func ConvertXlsxFull(filename, dirname string) {
+func ConvertXlsxFull(filename, dirname, compression string) {
 	xlsxFile, err := xlsx.OpenFile(filename)
 	if err != nil {
 		ExitWithError(err)
@@ -65,20 +71,24 @@ func ConvertXlsxFull(filename, dirname string) {
 		ExitWithError(err)
 	}
 	for _, sheet := range xlsxFile.Sheets {
-		ConvertXlsxSheetToDirectory(dirname, sheet)
+		ConvertXlsxSheetToDirectory(dirname, sheet, compression)
 	}
 }
 
-func ConvertXlsxSheetToDirectory(dirname string, sheet *xlsx.Sheet) {
-	filename := fmt.Sprintf("%s/%s.csv", dirname, sheet.Name)
+func ConvertXlsxSheetToDirectory(dirname string, sheet *xlsx.Sheet, compression string) {
+	filename := AddCompressionExtension(fmt.Sprintf("%s/%s.csv", dirname, sheet.Name), compression)
 
-	file, err := os.Create(filename)
+	file, err := CreateOutputFile(filename, compression, false)
 	if err != nil {
 		ExitWithError(err)
 	}
-	defer file.Close()
-	outputCsv := NewOutputCsvFromFile(file)
+	outputCsv := NewOutputCsvFromWriter(file)
+	outputCsv.KeepHeader()
 	WriteSheetToOutputCsv(sheet, outputCsv)
+	err = file.Close()
+	if err != nil {
+		ExitWithError(err)
+	}
 }
 
 func ConvertXlsxSheet(filename, sheetName string) {
@@ -98,6 +108,7 @@ func ConvertXlsxSheet(filename, sheetName string) {
 
 	sheet := xlsxFile.Sheets[sheetIndex]
 	outputCsv := NewOutputCsv()
+	outputCsv.KeepHeader()
 	WriteSheetToOutputCsv(sheet, outputCsv)
 }
 
@@ -6,6 +6,7 @@ import (
 )
 
 type ZipSubcommand struct {
+	inputFiles InputFilesFlags
 }
 
 func (sub *ZipSubcommand) Name() string {
@@ -18,10 +19,11 @@ func (sub *ZipSubcommand) Description() string {
 	return "Zip multiple CSVs into one CSV."
 }
 func (sub *ZipSubcommand) SetFlags(fs *flag.FlagSet) {
+	sub.inputFiles.SetFlags(fs)
 }
 
 func (sub *ZipSubcommand) Run(args []string) {
-	filenames := args
+	filenames := sub.inputFiles.ExpandFilenamesOrPanic(args)
 	inputCsvs := GetInputCsvsOrPanic(filenames, -1)
 	ZipFiles(inputCsvs)
 }
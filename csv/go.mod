@@ -1 +1,3 @@
 module github.com/DataFoxCo/gocsv/csv
+
+go 1.16
@@ -88,7 +88,7 @@ var (
 var errInvalidDelim = errors.New("csv: invalid field or comment delimiter")
 
 func validDelim(r rune) bool {
-	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+	return r != 0 && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
 }
 
 // A Reader reads records from a CSV-encoded file.
@@ -107,6 +107,26 @@ type Reader struct {
 	// or the Unicode replacement character (0xFFFD).
 	Comma rune
 
+	// Delimiter, if not empty, is the field delimiter instead of Comma,
+	// and may be more than one character long, such as "||" or "~|~".
+	// Its characters must be valid as Comma, and it must not contain
+	// Quote or Escape.
+	Delimiter string
+
+	// Quote is the character enclosing quoted fields.
+	// It is set to the double quote ('"') by NewReader.
+	// Within a quoted field, a doubled Quote is read as a single Quote.
+	// If Quote is 0, no field is quoted and Quote characters are read as is.
+	// Quote must not be equal to Comma or Comment.
+	Quote rune
+
+	// Escape, if not 0, is the escape character. Escape followed by any
+	// character is read as that character, both within and outside quoted
+	// fields, so it can escape Comma, Quote, newlines and Escape itself,
+	// as in the output of MySQL's SELECT ... INTO OUTFILE.
+	// Escape must not be equal to Comma or Quote.
+	Escape rune
+
 	// Comment, if not 0, is the comment character. Lines beginning with the
 	// Comment character without preceding whitespace are ignored.
 	// With leading whitespace the Comment character becomes part of the
@@ -165,6 +185,7 @@ type Reader struct {
 func NewReader(r io.Reader) *Reader {
 	return &Reader{
 		Comma: ',',
+		Quote: '"',
 		r:     bufio.NewReader(r),
 	}
 }
@@ -249,8 +270,53 @@ func nextRune(b []byte) rune {
 	return r
 }
 
+// indexUnquoted returns the index of the first delimiter, Escape or bare
+// Quote in an unquoted field, or -1 if there is none.
+func (r *Reader) indexUnquoted(line, comma []byte) int {
+	i := bytes.IndexFunc(line, func(c rune) bool {
+		return c == r.Escape || (c == r.Quote && r.Quote != 0 && !r.LazyQuotes)
+	})
+	if j := bytes.Index(line, comma); j >= 0 && (i < 0 || j < i) {
+		return j
+	}
+	return i
+}
+
+// indexQuoted returns the index of the first Quote or Escape in a quoted
+// field, or -1 if there is none.
+func (r *Reader) indexQuoted(line []byte) int {
+	if r.Escape == 0 {
+		return bytes.IndexRune(line, r.Quote)
+	}
+	return bytes.IndexFunc(line, func(c rune) bool {
+		return c == r.Quote || c == r.Escape
+	})
+}
+
+// delimiter returns the field delimiter, which is Delimiter if it is set
+// or else Comma.
+func (r *Reader) delimiter() string {
+	if r.Delimiter != "" {
+		return r.Delimiter
+	}
+	return string(r.Comma)
+}
+
+// validDelims reports whether the delimiter and the quote, escape and
+// comment characters are valid and distinct from each other.
+func (r *Reader) validDelims() bool {
+	for i, c := range r.delimiter() {
+		if !validDelim(c) || c == r.Quote || c == r.Escape || (i == 0 && c == r.Comment) {
+			return false
+		}
+	}
+	return (r.Comment == 0 || validDelim(r.Comment)) &&
+		(r.Quote == 0 || (validDelim(r.Quote) && r.Quote != r.Comment)) &&
+		(r.Escape == 0 || (validDelim(r.Escape) && r.Escape != r.Quote))
+}
+
 func (r *Reader) readRecord(dst []string) ([]string, error) {
-	if r.Comma == r.Comment || !validDelim(r.Comma) || (r.Comment != 0 && !validDelim(r.Comment)) {
+	if !r.validDelims() {
 		return nil, errInvalidDelim
 	}
 
@@ -272,8 +338,13 @@ func (r *Reader) readRecord(dst []string) ([]string, error) {
 
 	// Parse each field in the record.
 	var err error
-	const quoteLen = len(`"`)
-	commaLen := utf8.RuneLen(r.Comma)
+	var quoteBuf, escapeBuf [utf8.UTFMax]byte
+	quote := quoteBuf[:utf8.EncodeRune(quoteBuf[:], r.Quote)]
+	escape := escapeBuf[:utf8.EncodeRune(escapeBuf[:], r.Escape)]
+	quoteLen := len(quote)
+	escapeLen := len(escape)
+	comma := []byte(r.delimiter())
+	commaLen := len(comma)
 	recLine := r.numLine // Starting line for record
 	r.recordBuffer = r.recordBuffer[:0]
 	r.fieldIndexes = r.fieldIndexes[:0]
@@ -282,9 +353,58 @@ parseField:
 		if r.TrimLeadingSpace {
 			line = bytes.TrimLeftFunc(line, unicode.IsSpace)
 		}
-		if len(line) == 0 || line[0] != '"' {
+		if r.Quote == 0 || len(line) == 0 || nextRune(line) != r.Quote {
+			if r.Escape != 0 {
+				// Non-quoted string field with escapes, which continues
+				// on the next line after an escaped newline.
+				for {
+					i := r.indexUnquoted(line, comma)
+					if i < 0 {
+						r.recordBuffer = append(r.recordBuffer, line[:len(line)-lengthNL(line)]...)
+						r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
+						break parseField
+					}
+					r.recordBuffer = append(r.recordBuffer, line[:i]...)
+					line = line[i:]
+					switch rn := nextRune(line); {
+					case bytes.HasPrefix(line, comma):
+						// End of field.
+						line = line[commaLen:]
+						r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
+						continue parseField
+					case rn == r.Escape:
+						line = line[escapeLen:]
+						if len(line) > 0 && lengthNL(line) == len(line) {
+							// Escaped newline (append newline and read next line).
+							r.recordBuffer = append(r.recordBuffer, '\n')
+							if errRead != nil {
+								break parseField
+							}
+							line, errRead = r.readLine()
+							if errRead == io.EOF {
+								errRead = nil
+							}
+							fullLine = line
+						} else if len(line) > 0 {
+							// Escaped character (append it verbatim).
+							_, size := utf8.DecodeRune(line)
+							r.recordBuffer = append(r.recordBuffer, line[:size]...)
+							line = line[size:]
+						} else {
+							// Escape at end of file (append it verbatim).
+							r.recordBuffer = append(r.recordBuffer, escape...)
+						}
+					default:
+						// Bare quote.
+						col := utf8.RuneCount(fullLine[:len(fullLine)-len(line)])
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: ErrBareQuote}
+						break parseField
+					}
+				}
+			}
+
 			// Non-quoted string field
-			i := bytes.IndexRune(line, r.Comma)
+			i := bytes.Index(line, comma)
 			field := line
 			if i >= 0 {
 				field = field[:i]
@@ -292,8 +412,8 @@ parseField:
 				field = field[:len(field)-lengthNL(field)]
 			}
 			// Check to make sure a quote does not appear in field.
-			if !r.LazyQuotes {
-				if j := bytes.IndexByte(field, '"'); j >= 0 {
+			if !r.LazyQuotes && r.Quote != 0 {
+				if j := bytes.IndexRune(field, r.Quote); j >= 0 {
 					col := utf8.RuneCount(fullLine[:len(fullLine)-len(line[j:])])
 					err = &ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: ErrBareQuote}
 					break parseField
@@ -310,17 +430,31 @@ parseField:
 			// Quoted string field
 			line = line[quoteLen:]
 			for {
-				i := bytes.IndexByte(line, '"')
-				if i >= 0 {
+				i := r.indexQuoted(line)
+				if i >= 0 && r.Escape != 0 && nextRune(line[i:]) == r.Escape {
+					// Hit escape.
+					r.recordBuffer = append(r.recordBuffer, line[:i]...)
+					line = line[i+escapeLen:]
+					if len(line) == 0 {
+						// Escape at end of file (append it verbatim).
+						r.recordBuffer = append(r.recordBuffer, escape...)
+					} else if lengthNL(line) < len(line) {
+						// Escaped character (append it verbatim). An
+						// escaped newline is left to be copied as usual.
+						_, size := utf8.DecodeRune(line)
+						r.recordBuffer = append(r.recordBuffer, line[:size]...)
+						line = line[size:]
+					}
+				} else if i >= 0 {
 					// Hit next quote.
 					r.recordBuffer = append(r.recordBuffer, line[:i]...)
 					line = line[i+quoteLen:]
 					switch rn := nextRune(line); {
-					case rn == '"':
+					case rn == r.Quote:
 						// `""` sequence (append quote).
-						r.recordBuffer = append(r.recordBuffer, '"')
+						r.recordBuffer = append(r.recordBuffer, quote...)
 						line = line[quoteLen:]
-					case rn == r.Comma:
+					case bytes.HasPrefix(line, comma):
 						// `",` sequence (end of field).
 						line = line[commaLen:]
 						r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
@@ -331,7 +465,7 @@ parseField:
 						break parseField
 					case r.LazyQuotes:
 						// `"` sequence (bare quote).
-						r.recordBuffer = append(r.recordBuffer, '"')
+						r.recordBuffer = append(r.recordBuffer, quote...)
 					default:
 						// `"*` sequence (invalid non-escaped quote).
 						col := utf8.RuneCount(fullLine[:len(fullLine)-len(line)-quoteLen])
@@ -21,6 +21,10 @@ func TestRead(t *testing.T) {
 
 		// These fields are copied into the Reader
 		Comma              rune
+		Delimiter          string
+		Quote              rune
+		NoQuote            bool // true means Quote is 0
+		Escape             rune
 		Comment            rune
 		UseFieldsPerRecord bool // false (default) means FieldsPerRecord is -1
 		FieldsPerRecord    int
@@ -402,6 +406,151 @@ x,,,
 		Comma:   'X',
 		Comment: 'X',
 		Error:   errInvalidDelim,
+	}, {
+		Name:   "SingleQuote",
+		Input:  "'a,b','c''d',\"e\"\n",
+		Output: [][]string{{"a,b", "c'd", `"e"`}},
+		Quote:  '\'',
+	}, {
+		Name:   "SingleQuoteMultiLine",
+		Input:  "'two\nline',x\n",
+		Output: [][]string{{"two\nline", "x"}},
+		Quote:  '\'',
+	}, {
+		Name:  "SingleQuoteBareQuote",
+		Input: "a'b\n",
+		Error: &ParseError{StartLine: 1, Line: 1, Column: 1, Err: ErrBareQuote},
+		Quote: '\'',
+	}, {
+		Name:    "NoQuote",
+		Input:   "\"a,b\"\n",
+		Output:  [][]string{{`"a`, `b"`}},
+		NoQuote: true,
+	}, {
+		Name:   "Escape",
+		Input:  `a\,b,c\\d,e\"f` + "\n",
+		Output: [][]string{{"a,b", `c\d`, `e"f`}},
+		Escape: '\\',
+	}, {
+		Name:   "EscapeNewline",
+		Input:  "a\\\nb,c\nd,e\n",
+		Output: [][]string{{"a\nb", "c"}, {"d", "e"}},
+		Escape: '\\',
+	}, {
+		Name:   "EscapeCRLF",
+		Input:  "a\\\r\nb,c\r\n",
+		Output: [][]string{{"a\nb", "c"}},
+		Escape: '\\',
+	}, {
+		Name:   "EscapeAtEOF",
+		Input:  `a,b\`,
+		Output: [][]string{{"a", `b\`}},
+		Escape: '\\',
+	}, {
+		Name:   "EscapeInQuotes",
+		Input:  `"a\"b","c\\","d""e"` + "\n",
+		Output: [][]string{{`a"b`, `c\`, `d"e`}},
+		Escape: '\\',
+	}, {
+		Name:   "EscapeNewlineInQuotes",
+		Input:  "\"a\\\nb\",c\n",
+		Output: [][]string{{"a\nb", "c"}},
+		Escape: '\\',
+	}, {
+		Name:   "EscapeBareQuote",
+		Input:  `a\"b"c` + "\n",
+		Error:  &ParseError{StartLine: 1, Line: 1, Column: 4, Err: ErrBareQuote},
+		Escape: '\\',
+	}, {
+		Name:       "EscapeLazyQuotes",
+		Input:      `a"b\,c` + "\n",
+		Output:     [][]string{{`a"b,c`}},
+		Escape:     '\\',
+		LazyQuotes: true,
+	}, {
+		Name:    "MySQLOutfile",
+		Input:   "1\tit\\'s\t\\N\n2\ttab\\\there\tx\n",
+		Output:  [][]string{{"1", "it's", "N"}, {"2", "tab\there", "x"}},
+		Comma:   '\t',
+		NoQuote: true,
+		Escape:  '\\',
+	}, {
+		Name:  "QuoteIsComma",
+		Comma: '\'',
+		Quote: '\'',
+		Error: errInvalidDelim,
+	}, {
+		Name:    "QuoteIsComment",
+		Quote:   '#',
+		Comment: '#',
+		Error:   errInvalidDelim,
+	}, {
+		Name:   "EscapeIsComma",
+		Escape: ',',
+		Error:  errInvalidDelim,
+	}, {
+		Name:   "EscapeIsQuote",
+		Escape: '"',
+		Error:  errInvalidDelim,
+	}, {
+		Name:   "BadEscape",
+		Escape: '\n',
+		Error:  errInvalidDelim,
+	}, {
+		Name:      "MultiCharDelimiter",
+		Input:     "a||b||c\nd||e||\n",
+		Output:    [][]string{{"a", "b", "c"}, {"d", "e", ""}},
+		Delimiter: "||",
+	}, {
+		Name:      "MultiCharDelimiterPartial",
+		Input:     "a|b||c|\n|d|||e\n",
+		Output:    [][]string{{"a|b", "c|"}, {"|d", "|e"}},
+		Delimiter: "||",
+	}, {
+		Name:      "TildePipeDelimiter",
+		Input:     "a~|~b~c~|~|d\n",
+		Output:    [][]string{{"a", "b~c", "|d"}},
+		Delimiter: "~|~",
+	}, {
+		Name:      "MultiCharDelimiterQuoted",
+		Input:     `"a||b"||"c""d"` + "\n",
+		Output:    [][]string{{"a||b", `c"d`}},
+		Delimiter: "||",
+	}, {
+		Name:      "MultiCharDelimiterQuoteError",
+		Input:     `"a"|b` + "\n",
+		Error:     &ParseError{StartLine: 1, Line: 1, Column: 2, Err: ErrQuote},
+		Delimiter: "||",
+	}, {
+		Name:      "MultiCharDelimiterEscape",
+		Input:     `a\|\|b||c\\||d` + "\n",
+		Output:    [][]string{{"a||b", `c\`, "d"}},
+		Delimiter: "||",
+		Escape:    '\\',
+	}, {
+		Name:             "MultiCharDelimiterTrimLeadingSpace",
+		Input:            "a||  b|| c\n",
+		Output:           [][]string{{"a", "b", "c"}},
+		Delimiter:        "||",
+		TrimLeadingSpace: true,
+	}, {
+		Name:      "BadMultiCharDelimiter1",
+		Delimiter: "|\n",
+		Error:     errInvalidDelim,
+	}, {
+		Name:      "BadMultiCharDelimiter2",
+		Delimiter: "|\"",
+		Error:     errInvalidDelim,
+	}, {
+		Name:      "BadMultiCharDelimiter3",
+		Delimiter: "\\|",
+		Escape:    '\\',
+		Error:     errInvalidDelim,
+	}, {
+		Name:      "BadMultiCharDelimiterComment",
+		Delimiter: "#|",
+		Comment:   '#',
+		Error:     errInvalidDelim,
 	}}
 
 	for _, tt := range tests {
@@ -411,6 +560,13 @@ x,,,
 			if tt.Comma != 0 {
 				r.Comma = tt.Comma
 			}
+			r.Delimiter = tt.Delimiter
+			if tt.Quote != 0 {
+				r.Quote = tt.Quote
+			} else if tt.NoQuote {
+				r.Quote = 0
+			}
+			r.Escape = tt.Escape
 			r.Comment = tt.Comment
 			if tt.UseFieldsPerRecord {
 				r.FieldsPerRecord = tt.FieldsPerRecord
@@ -6,31 +6,64 @@ package csv
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 )
 
+// A QuoteStyle determines which fields a Writer encloses in quotes.
+type QuoteStyle int
+
+const (
+	// QuoteMinimal quotes only the fields that need quotes.
+	QuoteMinimal QuoteStyle = iota
+	// QuoteAll quotes every field, including empty fields.
+	QuoteAll
+	// QuoteNonNumeric quotes every field that is not a number.
+	QuoteNonNumeric
+	// QuoteNone never quotes fields. Instead, the field delimiter, quotes,
+	// newlines and the escape character itself are preceded by Escape.
+	QuoteNone
+)
+
+// ErrNoEscape is returned when writing a field with special characters
+// using QuoteNone and no Escape character.
+var ErrNoEscape = errors.New("csv: field needs escaping but there is no escape character")
+
 // A Writer writes records to a CSV encoded file.
 //
 // As returned by NewWriter, a Writer writes records terminated by a
 // newline and uses ',' as the field delimiter. The exported fields can be
 // changed to customize the details before the first call to Write or WriteAll.
 //
-// Comma is the field delimiter.
+// Comma is the field delimiter, unless Delimiter is set to a delimiter
+// that may be more than one character long, such as "||".
 //
 // If UseCRLF is true, the Writer ends each output line with \r\n instead of \n.
+//
+// Quote is the character enclosing quoted fields, and Quoting determines
+// which fields are quoted.
+//
+// If Escape is not 0, it precedes Quote and Escape within quoted fields,
+// rather than doubling Quote, and the special characters of fields
+// written with QuoteNone.
 type Writer struct {
-	Comma   rune // Field delimiter (set to ',' by NewWriter)
-	UseCRLF bool // True to use \r\n as the line terminator
-	w       *bufio.Writer
+	Comma     rune       // Field delimiter (set to ',' by NewWriter)
+	Delimiter string     // Field delimiter instead of Comma, if not empty
+	Quote     rune       // Quote character (set to '"' by NewWriter)
+	UseCRLF   bool       // True to use \r\n as the line terminator
+	Quoting   QuoteStyle // Which fields to quote (QuoteMinimal by default)
+	Escape    rune       // Escape character (none by default)
+	w         *bufio.Writer
 }
 
 // NewWriter returns a new Writer that writes to w.
 func NewWriter(w io.Writer) *Writer {
 	return &Writer{
 		Comma: ',',
+		Quote: '"',
 		w:     bufio.NewWriter(w),
 	}
 }
@@ -38,32 +71,40 @@ func NewWriter(w io.Writer) *Writer {
 // Writer writes a single CSV record to w along with any necessary quoting.
 // A record is a slice of strings with each string being one field.
 func (w *Writer) Write(record []string) error {
-	if !validDelim(w.Comma) {
+	if !w.validDelims() {
 		return errInvalidDelim
 	}
+	comma := w.delimiter()
 
 	for n, field := range record {
 		if n > 0 {
-			if _, err := w.w.WriteRune(w.Comma); err != nil {
+			if _, err := w.w.WriteString(comma); err != nil {
+				return err
+			}
+		}
+
+		if w.Quoting == QuoteNone {
+			if err := w.writeEscapedField(field); err != nil {
 				return err
 			}
+			continue
 		}
 
 		// If we don't have to have a quoted field then just
 		// write out the field and continue to the next field.
-		if !w.fieldNeedsQuotes(field) {
+		if !w.fieldShouldBeQuoted(field) {
 			if _, err := w.w.WriteString(field); err != nil {
 				return err
 			}
 			continue
 		}
 
-		if err := w.w.WriteByte('"'); err != nil {
+		if _, err := w.w.WriteRune(w.Quote); err != nil {
 			return err
 		}
 		for len(field) > 0 {
 			// Search for special characters.
-			i := strings.IndexAny(field, "\"\r\n")
+			i := strings.IndexFunc(field, w.isSpecialInQuotes)
 			if i < 0 {
 				i = len(field)
 			}
@@ -77,9 +118,18 @@ func (w *Writer) Write(record []string) error {
 			// Encode the special character.
 			if len(field) > 0 {
 				var err error
-				switch field[0] {
-				case '"':
-					_, err = w.w.WriteString(`""`)
+				r, size := utf8.DecodeRuneInString(field)
+				switch r {
+				case w.Quote, w.Escape:
+					// Quotes are doubled unless there is an escape character.
+					if w.Escape != 0 {
+						_, err = w.w.WriteRune(w.Escape)
+					} else {
+						_, err = w.w.WriteRune(w.Quote)
+					}
+					if err == nil {
+						_, err = w.w.WriteRune(r)
+					}
 				case '\r':
 					if !w.UseCRLF {
 						err = w.w.WriteByte('\r')
@@ -91,13 +141,13 @@ func (w *Writer) Write(record []string) error {
 						err = w.w.WriteByte('\n')
 					}
 				}
-				field = field[1:]
+				field = field[size:]
 				if err != nil {
 					return err
 				}
 			}
 		}
-		if err := w.w.WriteByte('"'); err != nil {
+		if _, err := w.w.WriteRune(w.Quote); err != nil {
 			return err
 		}
 	}
@@ -133,8 +183,109 @@ func (w *Writer) WriteAll(records [][]string) error {
 	return w.w.Flush()
 }
 
+// writeEscapedField writes a field without quotes for QuoteNone, escaping
+// the characters that would otherwise need quotes.
+//
+// Every character of a delimiter longer than one character is escaped,
+// so that no part of one can be taken for the delimiter.
+func (w *Writer) writeEscapedField(field string) error {
+	comma := w.delimiter()
+	for _, r := range field {
+		if strings.ContainsRune(comma, r) || r == w.Quote || r == '\r' || r == '\n' || (w.Escape != 0 && r == w.Escape) {
+			if w.Escape == 0 {
+				return ErrNoEscape
+			}
+			if _, err := w.w.WriteRune(w.Escape); err != nil {
+				return err
+			}
+		}
+		if _, err := w.w.WriteRune(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSpecialInQuotes reports whether a character within a quoted field
+// needs to be encoded.
+func (w *Writer) isSpecialInQuotes(r rune) bool {
+	return r == w.Quote || (r == w.Escape && w.Escape != 0) || r == '\r' || r == '\n'
+}
+
+// delimiter returns the field delimiter, which is Delimiter if it is set
+// or else Comma.
+func (w *Writer) delimiter() string {
+	if w.Delimiter != "" {
+		return w.Delimiter
+	}
+	return string(w.Comma)
+}
+
+// validDelims reports whether the delimiter and the quote and escape
+// characters are valid and distinct from each other.
+func (w *Writer) validDelims() bool {
+	for _, c := range w.delimiter() {
+		if !validDelim(c) || c == w.Quote || c == w.Escape {
+			return false
+		}
+	}
+	return validDelim(w.Quote) && (w.Escape == 0 || (validDelim(w.Escape) && w.Escape != w.Quote))
+}
+
+// fieldShouldBeQuoted reports whether a field is enclosed in quotes given
+// the quoting style.
+func (w *Writer) fieldShouldBeQuoted(field string) bool {
+	switch w.Quoting {
+	case QuoteAll:
+		return true
+	case QuoteNonNumeric:
+		if !isDecimalNumber(field) {
+			return true
+		}
+	}
+	return w.fieldNeedsQuotes(field)
+}
+
+// isDecimalNumber reports whether a field is a plain decimal number, with
+// an optional sign, fraction and exponent, such as -2, 1.5 or 1e3. Other
+// forms that strconv.ParseFloat accepts, such as NaN, Inf, hexadecimal
+// and underscores between digits, are not numbers to other programs.
+func isDecimalNumber(field string) bool {
+	i := 0
+	if i < len(field) && (field[i] == '+' || field[i] == '-') {
+		i++
+	}
+	numDigits := 0
+	for ; i < len(field) && '0' <= field[i] && field[i] <= '9'; i++ {
+		numDigits++
+	}
+	if i < len(field) && field[i] == '.' {
+		i++
+		for ; i < len(field) && '0' <= field[i] && field[i] <= '9'; i++ {
+			numDigits++
+		}
+	}
+	if numDigits == 0 {
+		return false
+	}
+	if i < len(field) && (field[i] == 'e' || field[i] == 'E') {
+		i++
+		if i < len(field) && (field[i] == '+' || field[i] == '-') {
+			i++
+		}
+		numExponentDigits := 0
+		for ; i < len(field) && '0' <= field[i] && field[i] <= '9'; i++ {
+			numExponentDigits++
+		}
+		if numExponentDigits == 0 {
+			return false
+		}
+	}
+	return i == len(field)
+}
+
 // fieldNeedsQuotes reports whether our field must be enclosed in quotes.
-// Fields with a Comma, fields with a quote or newline, and
+// Fields with a delimiter, fields with a quote, escape or newline, and
 // fields which start with a space must be enclosed in quotes.
 // We used to quote empty strings, but we do not anymore (as of Go 1.4).
 // The two representations should be equivalent, but Postgres distinguishes
@@ -149,7 +300,19 @@ func (w *Writer) fieldNeedsQuotes(field string) bool {
 	if field == "" {
 		return false
 	}
-	if field == `\.` || strings.ContainsRune(field, w.Comma) || strings.ContainsAny(field, "\"\r\n") {
+	if field == `\.` || strings.ContainsRune(field, w.Quote) || strings.ContainsAny(field, "\r\n") {
+		return true
+	}
+	if w.Delimiter == "" {
+		if strings.ContainsRune(field, w.Comma) {
+			return true
+		}
+	} else if strings.Index(field+w.Delimiter, w.Delimiter) < len(field) {
+		// The field contains the delimiter, or ends with the start of it,
+		// as "a|" does with "||", so it would be split when read.
+		return true
+	}
+	if w.Escape != 0 && strings.ContainsRune(field, w.Escape) {
 		return true
 	}
 
@@ -11,11 +11,15 @@ import (
 )
 
 var writeTests = []struct {
-	Input   [][]string
-	Output  string
-	Error   error
-	UseCRLF bool
-	Comma   rune
+	Input     [][]string
+	Output    string
+	Error     error
+	UseCRLF   bool
+	Comma     rune
+	Delimiter string
+	Quote     rune
+	Quoting   QuoteStyle
+	Escape    rune
 }{
 	{Input: [][]string{{"abc"}}, Output: "abc\n"},
 	{Input: [][]string{{"abc"}}, Output: "abc\r\n", UseCRLF: true},
@@ -46,6 +50,29 @@ var writeTests = []struct {
 	{Input: [][]string{{"a", "a", ""}}, Output: "a|a|\n", Comma: '|'},
 	{Input: [][]string{{",", ",", ""}}, Output: ",|,|\n", Comma: '|'},
 	{Input: [][]string{{"foo"}}, Comma: '"', Error: errInvalidDelim},
+	{Input: [][]string{{"a", "", "1.5"}}, Output: `"a","","1.5"` + "\n", Quoting: QuoteAll},
+	{Input: [][]string{{"a\"b", "c,d"}}, Output: `"a""b","c,d"` + "\n", Quoting: QuoteAll},
+	{Input: [][]string{{"a", "", "1.5", "-2", "1e3", " 3"}}, Output: `"a","",1.5,-2,1e3," 3"` + "\n", Quoting: QuoteNonNumeric},
+	{Input: [][]string{{"+.5", "5.", "-1.5E-3", "NaN", "Inf", "-infinity", "0x1p-2", "1_000", "1e", ".", "1.2.3"}}, Output: `+.5,5.,-1.5E-3,"NaN","Inf","-infinity","0x1p-2","1_000","1e",".","1.2.3"` + "\n", Quoting: QuoteNonNumeric},
+	{Input: [][]string{{"a", " b", `\.`}}, Output: "a, b,\\.\n", Quoting: QuoteNone},
+	{Input: [][]string{{"a,b", "c\"d", "e\nf", `g\h`}}, Output: `a\,b,c\"d,e\` + "\n" + `f,g\\h` + "\n", Quoting: QuoteNone, Escape: '\\'},
+	{Input: [][]string{{"a|b", "c"}}, Output: `a\|b|c` + "\n", Comma: '|', Quoting: QuoteNone, Escape: '\\'},
+	{Input: [][]string{{"a,b"}}, Quoting: QuoteNone, Error: ErrNoEscape},
+	{Input: [][]string{{"a"}}, Quoting: QuoteNone, Escape: ',', Error: errInvalidDelim},
+	{Input: [][]string{{"a'b", `c"d`, "e,f"}}, Output: `'a''b',c"d,'e,f'` + "\n", Quote: '\''},
+	{Input: [][]string{{"a"}}, Quote: '\'', Quoting: QuoteAll, Output: "'a'\n"},
+	{Input: [][]string{{"a"}}, Comma: '\'', Quote: '\'', Error: errInvalidDelim},
+	{Input: [][]string{{"a"}}, Quote: '\n', Error: errInvalidDelim},
+	{Input: [][]string{{`a"b`, `c\d`, "e"}}, Output: `"a\"b","c\\d",e` + "\n", Escape: '\\'},
+	{Input: [][]string{{`a"b`}}, Output: `"a\"b"` + "\n", Quoting: QuoteAll, Escape: '\\'},
+	{Input: [][]string{{"it's", "x,y"}}, Output: `it\'s,x\,y` + "\n", Quote: '\'', Quoting: QuoteNone, Escape: '\\'},
+	{Input: [][]string{{"a"}}, Escape: '"', Error: errInvalidDelim},
+	{Input: [][]string{{"a", "b", ""}}, Output: "a||b||\n", Delimiter: "||"},
+	{Input: [][]string{{"a", "b||c", "d|", "|e"}}, Output: `a||"b||c"||"d|"|||e` + "\n", Delimiter: "||"},
+	{Input: [][]string{{"a~", "b~|", "c,d"}}, Output: `a~~|~"b~|"~|~c,d` + "\n", Delimiter: "~|~"},
+	{Input: [][]string{{"a|b", "c"}}, Output: `a\|b||c` + "\n", Delimiter: "||", Quoting: QuoteNone, Escape: '\\'},
+	{Input: [][]string{{"a"}}, Delimiter: "|\"", Error: errInvalidDelim},
+	{Input: [][]string{{"a"}}, Delimiter: "|\r", Error: errInvalidDelim},
 }
 
 func TestWrite(t *testing.T) {
@@ -53,9 +80,15 @@ func TestWrite(t *testing.T) {
 		b := &bytes.Buffer{}
 		f := NewWriter(b)
 		f.UseCRLF = tt.UseCRLF
+		f.Delimiter = tt.Delimiter
 		if tt.Comma != 0 {
 			f.Comma = tt.Comma
 		}
+		if tt.Quote != 0 {
+			f.Quote = tt.Quote
+		}
+		f.Quoting = tt.Quoting
+		f.Escape = tt.Escape
 		err := f.WriteAll(tt.Input)
 		if err != tt.Error {
 			t.Errorf("Unexpected error:\ngot  %v\nwant %v", err, tt.Error)
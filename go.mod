@@ -1,5 +1,7 @@
 module github.com/DataFoxCo/gocsv
 
+go 1.16
+
 replace (
 	github.com/DataFoxCo/gocsv/cmd => ./cmd
 	github.com/DataFoxCo/gocsv/csv => ./csv